@@ -0,0 +1,80 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// accessRequestResource is the HTTP URL path component for the secret access
+// requests resource
+const accessRequestResource = "secret-access-requests"
+
+// The statuses of an AccessRequest
+const (
+	AccessRequestPending  = "Pending"
+	AccessRequestApproved = "Approved"
+	AccessRequestDenied   = "Denied"
+	AccessRequestCanceled = "Canceled"
+	AccessRequestExpired  = "Expired"
+)
+
+// AccessRequest is a request for access to a secret that requires approval
+// before it can be viewed. Its Status is one of the AccessRequest statuses,
+// and once it is AccessRequestApproved the secret can be read as usual, from
+// StartDate until ExpirationDate.
+type AccessRequest struct {
+	ID                              int `json:"SecretAccessRequestID"`
+	SecretID                        int
+	SecretName, Status              string
+	RequestComment, ResponseComment string
+	StartDate, ExpirationDate       Time
+}
+
+// Approved returns true if the access request has been approved
+func (r AccessRequest) Approved() bool {
+	return strings.EqualFold(r.Status, AccessRequestApproved)
+}
+
+// Pending returns true if the access request has yet to be approved or denied
+func (r AccessRequest) Pending() bool {
+	return strings.EqualFold(r.Status, AccessRequestPending)
+}
+
+// RequestSecretAccess requests access to the secret with the given id, for the
+// given reason, which the approvers are shown. Poll AccessRequestStatus with
+// the ID of the returned request to find out whether it was approved.
+func (s Server) RequestSecretAccess(id int, reason string) (*AccessRequest, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("[ERROR] a reason is required to request access to the secret with id '%d'", id)
+	}
+
+	input := struct {
+		SecretID       int
+		RequestComment string
+	}{id, reason}
+
+	return s.accessRequest("POST", "/", input)
+}
+
+// AccessRequestStatus returns the access request with the given id, whose
+// Status tells whether it has been approved
+func (s Server) AccessRequestStatus(requestID int) (*AccessRequest, error) {
+	return s.accessRequest("GET", strconv.Itoa(requestID), nil)
+}
+
+// accessRequest makes a request for the access request at the given path
+func (s Server) accessRequest(method, path string, input interface{}) (*AccessRequest, error) {
+	request := new(AccessRequest)
+
+	if data, err := s.accessResource(method, accessRequestResource, path, input); err == nil {
+		if err = s.unmarshal(data, request); err != nil {
+			s.logger().Errorf("error parsing response from /%s/%s: %s", accessRequestResource, path, redactBody(data))
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
+	return request, nil
+}
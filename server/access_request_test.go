@@ -0,0 +1,51 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// TestRequestSecretAccess validates that an access request is made with its
+// reason, and that its status can then be polled
+func TestRequestSecretAccess(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.handle("POST", "/api/v1/secret-access-requests", func(w http.ResponseWriter, r *http.Request) {
+		input := struct {
+			SecretID       int
+			RequestComment string
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			t.Error("parsing the access request:", err)
+		}
+		validate("secret id", 42, input.SecretID, t)
+		validate("request comment", "deploying", input.RequestComment, t)
+		w.Write([]byte(`{"secretAccessRequestId": 9, "secretId": 42, "status": "Pending", "requestComment": "deploying"}`))
+	})
+	f.respond("GET", "/api/v1/secret-access-requests/9", http.StatusOK,
+		`{"secretAccessRequestId": 9, "secretId": 42, "status": "Approved", "responseComment": "ok",
+		"startDate": "2024-03-01T10:00:00", "expirationDate": "2024-03-01T12:00:00"}`)
+
+	tss := f.server()
+	if _, err := tss.RequestSecretAccess(42, " "); err == nil {
+		t.Error("expecting an error when no reason is given")
+	}
+
+	request, err := tss.RequestSecretAccess(42, "deploying")
+	if err != nil {
+		t.Fatal("calling server.RequestSecretAccess:", err)
+	}
+	if !validate("access request id", 9, request.ID, t) || !request.Pending() {
+		t.Fatalf("expecting a pending access request, but found %+v", request)
+	}
+
+	request, err = tss.AccessRequestStatus(request.ID)
+	if err != nil {
+		t.Fatal("calling server.AccessRequestStatus:", err)
+	}
+	if !request.Approved() || request.ExpirationDate.Hour() != 12 {
+		t.Errorf("expecting an approved access request until 12:00, but found %+v", request)
+	}
+}
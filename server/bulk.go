@@ -0,0 +1,240 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// bulkSecretOperationResource is the HTTP URL path component for the bulk
+// secret operations resource, which starts operations on many secrets at once
+const bulkSecretOperationResource = "bulk-secret-operations"
+
+// bulkOperationResource is the HTTP URL path component for the bulk
+// operations resource, which reports the progress of the operations started
+const bulkOperationResource = "bulk-operations"
+
+// bulkOperationPollInterval is how long to wait before asking again for the
+// progress of a bulk operation that has not completed
+const bulkOperationPollInterval = time.Second
+
+// bulkOperationMaxWait is how long, at most, to wait for a bulk operation to
+// complete before giving up on it
+const bulkOperationMaxWait = 10 * time.Minute
+
+// bulkOperationProgress is the progress of a bulk operation, with an error for
+// each of the secrets that it failed on
+type bulkOperationProgress struct {
+	BulkOperationID string
+	IsComplete      bool
+	Errors          []struct {
+		ItemID       int
+		ErrorMessage string
+	}
+}
+
+// BulkError is returned by bulk operations when some of the secrets could not
+// be processed; Errors holds the error for each of them by secret id
+type BulkError struct {
+	Errors map[int]error
+}
+
+func (e *BulkError) Error() string {
+	ids := make([]int, 0, len(e.Errors))
+	for id := range e.Errors {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	messages := make([]string, len(ids))
+	for i, id := range ids {
+		messages[i] = fmt.Sprintf("%d: %s", id, e.Errors[id])
+	}
+	return fmt.Sprintf("%d of the secrets failed: %s", len(ids), strings.Join(messages, "; "))
+}
+
+// SecretsByID gets the secrets with the given ids concurrently, making up to
+// BulkConcurrency requests at a time, and returns them by id. If any of them
+// fail, the secrets that did not are returned along with a *BulkError.
+func (s Server) SecretsByID(ids []int) (map[int]*Secret, error) {
+	return s.secretsByID(context.Background(), ids)
+}
+
+// SecretsByIDWithContext is SecretsByID with a ctx that governs the requests
+// made. Once ctx ends, no more secrets are requested and the requests in
+// flight are canceled, and the secrets that were fetched by then are returned
+// along with ctx.Err().
+func (s Server) SecretsByIDWithContext(ctx context.Context, ids []int) (map[int]*Secret, error) {
+	return s.secretsByID(ctx, ids)
+}
+
+// secretsByID is SecretsByID with a ctx that governs the requests made
+func (s Server) secretsByID(ctx context.Context, ids []int) (map[int]*Secret, error) {
+	secrets := make(map[int]*Secret, len(ids))
+	errs := make(map[int]error)
+	var mutex sync.Mutex
+
+	concurrency := s.BulkConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultBulkConcurrency
+	}
+
+	jobs := make(chan int)
+	var workers sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for id := range jobs {
+				secret, err := s.SecretWithContext(ctx, id)
+
+				mutex.Lock()
+				if err == nil {
+					secrets[id] = secret
+				} else {
+					errs[id] = err
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	queued := make(map[int]bool, len(ids))
+queue:
+	for _, id := range ids {
+		if !queued[id] {
+			queued[id] = true
+			select {
+			case jobs <- id:
+			case <-ctx.Done():
+				break queue
+			}
+		}
+	}
+	close(jobs)
+	workers.Wait()
+
+	if ctx.Err() != nil {
+		return secrets, ctx.Err()
+	}
+	if len(errs) > 0 {
+		return secrets, &BulkError{Errors: errs}
+	}
+	return secrets, nil
+}
+
+// BulkMoveSecrets moves the secrets with the given ids to the folder with the
+// given id in one bulk operation, rather than a request per secret, and waits
+// up to 10 minutes for it to complete. If it fails on any of them, it returns
+// a *BulkError with the server's reason for each.
+func (s Server) BulkMoveSecrets(ids []int, targetFolderID int) error {
+	return s.BulkMoveSecretsWithContext(context.Background(), ids, targetFolderID)
+}
+
+// BulkMoveSecretsWithContext is BulkMoveSecrets with a ctx that governs the
+// requests made and the wait for the operation to complete. If ctx ends
+// first, ctx.Err() is returned, and the operation may still complete.
+func (s Server) BulkMoveSecretsWithContext(ctx context.Context, ids []int, targetFolderID int) error {
+	input := struct {
+		Data struct {
+			FolderID  int   `json:"folderId"`
+			SecretIDs []int `json:"secretIds"`
+		} `json:"data"`
+	}{}
+	input.Data.FolderID = targetFolderID
+	input.Data.SecretIDs = ids
+
+	return s.bulkSecretOperation(ctx, "move-to-folder", ids, input)
+}
+
+// BulkDeleteSecrets deletes the secrets with the given ids in one bulk
+// operation, rather than a request per secret, and waits up to 10 minutes for
+// it to complete. If it fails on any of them, it returns a *BulkError with the
+// server's reason for each.
+func (s Server) BulkDeleteSecrets(ids []int) error {
+	return s.BulkDeleteSecretsWithContext(context.Background(), ids)
+}
+
+// BulkDeleteSecretsWithContext is BulkDeleteSecrets with a ctx that governs
+// the requests made and the wait for the operation to complete. If ctx ends
+// first, ctx.Err() is returned, and the operation may still complete.
+func (s Server) BulkDeleteSecretsWithContext(ctx context.Context, ids []int) error {
+	input := struct {
+		Data struct {
+			SecretIDs []int `json:"secretIds"`
+		} `json:"data"`
+	}{}
+	input.Data.SecretIDs = ids
+
+	return s.bulkSecretOperation(ctx, "delete", ids, input)
+}
+
+// bulkSecretOperation starts the bulk operation at the given path on the given
+// secrets, and polls its progress until it completes, ctx ends or the
+// bulkOperationMaxWait has passed
+func (s Server) bulkSecretOperation(ctx context.Context, path string, ids []int, input interface{}) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if s.secrets != nil {
+		defer s.secrets.remove(ids...)
+	}
+
+	progress := new(bulkOperationProgress)
+
+	if data, err := s.accessResourceWithContext(ctx, "POST", bulkSecretOperationResource, path, input); err == nil {
+		if err = json.Unmarshal(data, progress); err != nil {
+			s.logger().Errorf("error parsing response from /%s/%s: %s", bulkSecretOperationResource, path, redactBody(data))
+			return err
+		}
+	} else {
+		return err
+	}
+	if s.DryRun {
+		return nil
+	}
+	if progress.BulkOperationID == "" {
+		return fmt.Errorf("[ERROR] the server did not return the id of the bulk operation '%s'", path)
+	}
+
+	progressPath := progress.BulkOperationID + "/progress"
+	deadline := time.Now().Add(bulkOperationMaxWait)
+	for {
+		if data, err := s.accessResourceWithContext(ctx, "GET", bulkOperationResource, progressPath, nil); err == nil {
+			if err = json.Unmarshal(data, progress); err != nil {
+				s.logger().Errorf("error parsing response from /%s/%s: %s", bulkOperationResource, progressPath, redactBody(data))
+				return err
+			}
+		} else {
+			return err
+		}
+		if progress.IsComplete {
+			break
+		}
+		if time.Now().Add(bulkOperationPollInterval).After(deadline) {
+			return fmt.Errorf("[ERROR] the bulk operation '%s' did not complete within %s", progress.BulkOperationID, bulkOperationMaxWait)
+		}
+
+		timer := time.NewTimer(bulkOperationPollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	if len(progress.Errors) > 0 {
+		errs := make(map[int]error, len(progress.Errors))
+		for _, failure := range progress.Errors {
+			errs[failure.ItemID] = errors.New(failure.ErrorMessage)
+		}
+		return &BulkError{Errors: errs}
+	}
+	return nil
+}
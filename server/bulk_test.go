@@ -0,0 +1,162 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestSecretsByID validates that the secrets that can be read are returned
+// along with a *BulkError for those that cannot.
+func TestSecretsByID(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	for id := 1; id <= 5; id++ {
+		f.respond("GET", fmt.Sprintf("/api/v1/secrets/%d", id), http.StatusOK, fmt.Sprintf(`{"ID": %d, "Name": "Secret %d"}`, id, id))
+	}
+
+	tss := f.server()
+	tss.BulkConcurrency = 2
+	secrets, err := tss.SecretsByID([]int{1, 2, 3, 4, 5, 6, 3})
+	var bulkErr *BulkError
+	if !errors.As(err, &bulkErr) {
+		t.Fatalf("expecting a *BulkError, but found '%v' instead", err)
+	}
+	if _, found := bulkErr.Errors[6]; !found || len(bulkErr.Errors) != 1 {
+		t.Errorf("expecting only secret 6 to fail, but found '%v' instead", err)
+	}
+	if len(secrets) != 5 || secrets[4] == nil || secrets[4].Name != "Secret 4" {
+		t.Errorf("expecting secrets 1 to 5, but found '%v' instead", secrets)
+	}
+	if count := f.count("GET", "/api/v1/secrets/3"); count != 1 {
+		t.Errorf("expecting secret 3 to be read once, but found %d times instead", count)
+	}
+	if count := f.count("POST", "/oauth2/token"); count != 1 {
+		t.Errorf("expecting 1 token request, but found %d instead", count)
+	}
+}
+
+// TestSecretsByIDWithContext validates that once the ctx ends, the request in
+// flight is canceled, no more are made, and the secrets fetched by then are
+// returned along with ctx.Err().
+func TestSecretsByIDWithContext(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	for id := 1; id <= 2; id++ {
+		f.respond("GET", fmt.Sprintf("/api/v1/secrets/%d", id), http.StatusOK, fmt.Sprintf(`{"ID": %d}`, id))
+	}
+	f.handle("GET", "/api/v1/secrets/3", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	tss := f.server()
+	tss.BulkConcurrency = 1
+	started := time.Now()
+	secrets, err := tss.SecretsByIDWithContext(ctx, []int{1, 2, 3, 4, 5})
+	if err != context.DeadlineExceeded {
+		t.Errorf("expecting context.DeadlineExceeded, but found '%v' instead", err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("expecting the request in flight to be canceled, but it took %s", elapsed)
+	}
+	if len(secrets) != 2 || secrets[1] == nil || secrets[2] == nil {
+		t.Errorf("expecting secrets 1 and 2, but found '%v' instead", secrets)
+	}
+	if count := f.count("GET", "/api/v1/secrets/5"); count != 0 {
+		t.Errorf("expecting secret 5 not to be requested, but found %d requests", count)
+	}
+}
+
+// TestBulkMoveSecrets validates that the secrets are moved in one bulk
+// operation, whose progress is polled until it completes, and that the
+// secrets that it failed on are returned in a *BulkError.
+func TestBulkMoveSecrets(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.handle("POST", "/api/v1/bulk-secret-operations/move-to-folder", func(w http.ResponseWriter, r *http.Request) {
+		input := struct {
+			Data struct {
+				FolderID  int
+				SecretIDs []int
+			}
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			t.Error("parsing the bulk operation:", err)
+		}
+		validate("folder id", 7, input.Data.FolderID, t)
+		validate("secret ids", 3, len(input.Data.SecretIDs), t)
+		w.Write([]byte(`{"bulkOperationId": "a1b2", "isComplete": false}`))
+	})
+	f.respond("GET", "/api/v1/bulk-operations/a1b2/progress", http.StatusOK,
+		`{"bulkOperationId": "a1b2", "isComplete": true, "errors": [{"itemId": 2, "errorMessage": "Access denied"}]}`)
+
+	err := f.server().BulkMoveSecrets([]int{1, 2, 3}, 7)
+	var bulkErr *BulkError
+	if !errors.As(err, &bulkErr) {
+		t.Fatalf("expecting a *BulkError, but found '%v' instead", err)
+	}
+	if failure, found := bulkErr.Errors[2]; !found || len(bulkErr.Errors) != 1 || failure.Error() != "Access denied" {
+		t.Errorf("expecting only secret 2 to fail, but found '%v' instead", err)
+	}
+	if count := f.count("POST", "/api/v1/secrets/1"); count != 0 {
+		t.Errorf("expecting no request per secret, but found %d", count)
+	}
+}
+
+// TestBulkDeleteSecrets validates that the secrets are deleted in one bulk
+// operation, and that no request is made when there are no secrets.
+func TestBulkDeleteSecrets(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.respond("POST", "/api/v1/bulk-secret-operations/delete", http.StatusOK, `{"bulkOperationId": "c3d4"}`)
+	f.respond("GET", "/api/v1/bulk-operations/c3d4/progress", http.StatusOK, `{"bulkOperationId": "c3d4", "isComplete": true}`)
+
+	tss := f.server()
+	if err := tss.BulkDeleteSecrets(nil); err != nil {
+		t.Error("calling server.BulkDeleteSecrets without secrets:", err)
+	}
+	if count := f.count("POST", "/api/v1/bulk-secret-operations/delete"); count != 0 {
+		t.Errorf("expecting no bulk operation without secrets, but found %d", count)
+	}
+	if err := tss.BulkDeleteSecrets([]int{1, 2}); err != nil {
+		t.Error("calling server.BulkDeleteSecrets:", err)
+	}
+	if count := f.count("GET", "/api/v1/bulk-operations/c3d4/progress"); count != 1 {
+		t.Errorf("expecting the progress to be polled once, but found %d times", count)
+	}
+}
+
+// TestBulkDeleteSecretsWithContext validates that the wait for a bulk
+// operation that does not complete ends with ctx.
+func TestBulkDeleteSecretsWithContext(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.respond("POST", "/api/v1/bulk-secret-operations/delete", http.StatusOK, `{"bulkOperationId": "e5f6"}`)
+	f.respond("GET", "/api/v1/bulk-operations/e5f6/progress", http.StatusOK, `{"bulkOperationId": "e5f6", "isComplete": false}`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	if err := f.server().BulkDeleteSecretsWithContext(ctx, []int{1, 2}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expecting context.DeadlineExceeded, but found '%v' instead", err)
+	}
+	if elapsed := time.Since(started); elapsed > bulkOperationPollInterval {
+		t.Errorf("expecting the wait to end with ctx, but it took %s", elapsed)
+	}
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultCircuitBreakerCooldown is how long the circuit breaker stays open
+// when no CircuitBreakerCooldown is set
+const defaultCircuitBreakerCooldown = 30 * time.Second
+
+// ErrCircuitOpen is returned, without a request being made, while the circuit
+// breaker is open, i.e. after CircuitBreakerThreshold consecutive requests
+// failed and before the CircuitBreakerCooldown has passed
+var ErrCircuitOpen = errors.New("the circuit breaker is open after repeated failures to reach Secret Server")
+
+// circuitBreaker opens after threshold consecutive failures, and fails the
+// requests that are made while it is open until the cooldown has passed. Then
+// it half-opens to let one request through, which closes it if it succeeds
+// and opens it again if it fails.
+type circuitBreaker struct {
+	mutex     sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	open      bool
+	openedAt  time.Time
+	// probing is true while the request that tests whether the server has
+	// recovered is being made
+	probing bool
+}
+
+// newCircuitBreaker returns a closed circuitBreaker with the given threshold
+// and cooldown
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow returns ErrCircuitOpen if a request may not be made. Otherwise, it
+// returns whether the request is the one that tests whether the server has
+// recovered, whose outcome must then be recorded, or the probe released.
+func (b *circuitBreaker) allow() (bool, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !b.open {
+		return false, nil
+	}
+	if b.probing || time.Since(b.openedAt) < b.cooldown {
+		return false, ErrCircuitOpen
+	}
+	b.probing = true
+	return true, nil
+}
+
+// record counts the outcome of a request, opening the breaker at the threshold
+// of consecutive failures, or again if it was already open, and closing it on
+// a success
+func (b *circuitBreaker) record(failed bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.probing = false
+	if !failed {
+		b.failures = 0
+		b.open = false
+		return
+	}
+
+	b.failures++
+	if b.open || b.failures >= b.threshold {
+		b.open = true
+		b.openedAt = time.Now()
+	}
+}
+
+// release lets another request test whether the server has recovered, when
+// the probe ended without an outcome, e.g. because its ctx was canceled
+func (b *circuitBreaker) release() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.probing = false
+}
+
+// isFailure returns true if the request that ended with the given response,
+// which is nil if there was none, counts towards opening the circuit breaker;
+// that is, if the server could not be reached, or is overloaded or failing
+func isFailure(res *http.Response) bool {
+	return res == nil || res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= http.StatusInternalServerError
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestCircuitBreaker validates that the breaker opens after the threshold of
+// failed requests, fails requests without making them while it is open, and
+// closes when the request after the cooldown succeeds.
+func TestCircuitBreaker(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.respond("GET", "/api/v1/secrets/42", http.StatusServiceUnavailable, "")
+	f.respond("GET", "/api/v1/secrets/43", http.StatusNotFound, "")
+
+	tss, err := New(Configuration{
+		Credentials:             UserCredential{Username: "fixture-user", Password: "fixture-password"},
+		ServerURL:               f.URL,
+		RetryMaxAttempts:        1,
+		CircuitBreakerThreshold: 2,
+		CircuitBreakerCooldown:  50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal("configuring the Server:", err)
+	}
+
+	// a 404 is not a failure of the server, so it does not count
+	for _, id := range []int{42, 43, 42} {
+		if _, err := tss.Secret(id); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("expecting the error from the server for secret %d, but found '%v'", id, err)
+		}
+	}
+	if _, err := tss.Secret(42); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expecting the breaker to still be closed, but found '%v'", err)
+	}
+	if _, err := tss.Secret(42); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expecting ErrCircuitOpen, but found '%v'", err)
+	}
+	validate("requests", 3, f.count("GET", "/api/v1/secrets/42"), t)
+
+	// the request after the cooldown fails, so the breaker opens again
+	time.Sleep(60 * time.Millisecond)
+	if _, err := tss.Secret(42); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expecting the request after the cooldown to be made, but found '%v'", err)
+	}
+	if _, err := tss.Secret(42); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expecting ErrCircuitOpen, but found '%v'", err)
+	}
+
+	f.respond("GET", "/api/v1/secrets/42", http.StatusOK, `{"ID": 42}`)
+	time.Sleep(60 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		if _, err := tss.Secret(42); err != nil {
+			t.Fatal("expecting the breaker to close once the server has recovered, but found:", err)
+		}
+	}
+	validate("requests", 6, f.count("GET", "/api/v1/secrets/42"), t)
+}
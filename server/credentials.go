@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"net/url"
+)
+
+// CredentialProvider provides the credentials that the API exchanges for an
+// access token, as the form values of the request to the token endpoint, e.g.
+// grant_type, username and password. It is asked for them each time a new
+// token is needed, so it may fetch them from elsewhere, e.g. another vault, or
+// use a grant that exchanges a federated token. The tokens are cached as they
+// are for the Credentials.
+type CredentialProvider interface {
+	GrantValues(ctx context.Context) (url.Values, error)
+}
+
+// PasswordCredentialProvider provides the username and password of a user,
+// and their domain if it is set, for the PasswordGrant
+type PasswordCredentialProvider struct {
+	Domain, Username, Password string
+}
+
+// GrantValues returns the values of the PasswordGrant
+func (p PasswordCredentialProvider) GrantValues(ctx context.Context) (url.Values, error) {
+	values := url.Values{
+		"username":   {p.Username},
+		"password":   {p.Password},
+		"grant_type": {PasswordGrant},
+	}
+	if p.Domain != "" {
+		values["domain"] = []string{p.Domain}
+	}
+	return values, nil
+}
+
+// ClientCredentialProvider provides the client ID and secret of an
+// application account for the ClientCredentialsGrant
+type ClientCredentialProvider struct {
+	ClientID, ClientSecret string
+}
+
+// GrantValues returns the values of the ClientCredentialsGrant
+func (p ClientCredentialProvider) GrantValues(ctx context.Context) (url.Values, error) {
+	return url.Values{
+		"client_id":     {p.ClientID},
+		"client_secret": {p.ClientSecret},
+		"grant_type":    {ClientCredentialsGrant},
+	}, nil
+}
+
+// SDKClientCredentialProvider provides the credential of an SDK client
+// account, as SDKClientCredential reads it, for the ClientCredentialsGrant.
+// The client secret is read from the file at SecretPath each time a token is
+// needed, so the file can be replaced when the secret is rotated.
+type SDKClientCredentialProvider struct {
+	ClientID, SecretPath string
+}
+
+// GrantValues returns the values of the ClientCredentialsGrant with the client
+// secret read from its file
+func (p SDKClientCredentialProvider) GrantValues(ctx context.Context) (url.Values, error) {
+	credential, err := SDKClientCredential(p.ClientID, p.SecretPath)
+	if err != nil {
+		return nil, err
+	}
+	return ClientCredentialProvider{ClientID: credential.ClientID, ClientSecret: credential.ClientSecret}.GrantValues(ctx)
+}
+
+// credentialProvider returns the configured CredentialProvider, or if there is
+// none, the one for the Credentials and the GrantType
+func (s Server) credentialProvider() CredentialProvider {
+	switch {
+	case s.CredentialProvider != nil:
+		return s.CredentialProvider
+	case s.GrantType == ClientCredentialsGrant:
+		return ClientCredentialProvider{ClientID: s.Credentials.ClientID, ClientSecret: s.Credentials.ClientSecret}
+	default:
+		return PasswordCredentialProvider{
+			Domain:   s.Credentials.Domain,
+			Username: s.Credentials.Username,
+			Password: s.Credentials.Password,
+		}
+	}
+}
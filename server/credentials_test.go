@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// vaultProvider is a CredentialProvider that stands in for one that gets the
+// password from another vault
+type vaultProvider struct {
+	calls int
+}
+
+func (p *vaultProvider) GrantValues(ctx context.Context) (url.Values, error) {
+	p.calls++
+	if p.calls > 1 {
+		return nil, errors.New("the vault is sealed")
+	}
+	return PasswordCredentialProvider{Username: "vault-user", Password: "vault-password"}.GrantValues(ctx)
+}
+
+// TestCredentialProvider validates that the configured CredentialProvider
+// provides the values of the token request, that its token is cached, and
+// that its errors are returned.
+func TestCredentialProvider(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.handle("POST", "/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		validate("grant type", PasswordGrant, r.FormValue("grant_type"), t)
+		validate("username", "vault-user", r.FormValue("username"), t)
+		validate("password", "vault-password", r.FormValue("password"), t)
+		w.Write([]byte(`{"access_token": "fixture-token", "token_type": "bearer", "expires_in": 1200}`))
+	})
+	f.respond("GET", "/api/v1/secrets/42", http.StatusOK, `{"ID": 42}`)
+
+	provider := &vaultProvider{}
+	tss, err := NewWithOptions(WithServerURL(f.URL), WithCredentialProvider(provider))
+	if err != nil {
+		t.Fatal("calling NewWithOptions:", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := tss.Secret(42); err != nil {
+			t.Fatal("calling server.Secret:", err)
+		}
+	}
+	validate("provider calls", 1, provider.calls, t)
+
+	tss, err = NewWithOptions(WithServerURL(f.URL), WithCredentialProvider(provider))
+	if err != nil {
+		t.Fatal("calling NewWithOptions:", err)
+	}
+	if err := tss.Authenticate(); err == nil {
+		t.Error("expecting the provider's error")
+	}
+}
+
+// TestClientCredentialProvider validates the values of the client
+// credentials grant, including those of an SDK client.
+func TestClientCredentialProvider(t *testing.T) {
+	values, err := ClientCredentialProvider{ClientID: "id", ClientSecret: "secret"}.GrantValues(context.Background())
+	if err != nil {
+		t.Fatal("calling ClientCredentialProvider.GrantValues:", err)
+	}
+	validate("grant type", ClientCredentialsGrant, values.Get("grant_type"), t)
+	validate("client id", "id", values.Get("client_id"), t)
+	validate("client secret", "secret", values.Get("client_secret"), t)
+
+	if _, err := (SDKClientCredentialProvider{ClientID: "sdk-client-1", SecretPath: "missing"}).GrantValues(context.Background()); err == nil {
+		t.Error("expecting an error when the client secret file is missing")
+	}
+}
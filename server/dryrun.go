@@ -0,0 +1,28 @@
+package server
+
+import "strings"
+
+// dryRunResponse is the body that stands in for the response to the writes
+// that DryRun skips
+var dryRunResponse = []byte("{}")
+
+// isWrite returns true if the request with the given method, for the given
+// resource and path, changes something on the server, and so is skipped when
+// DryRun is set. Some POSTs only read, such as those for restricted secrets,
+// which send the comment in the body, runs of a heartbeat and generated
+// passwords.
+func isWrite(method, resource, path string) bool {
+	switch method {
+	case "GET":
+		return false
+	case "POST":
+		switch {
+		case resource == templateResource && strings.HasPrefix(path, "generate-password/"):
+			return false
+		case resource == "secrets" && (strings.HasSuffix(path, "/restricted") ||
+			strings.Contains(path, "/restricted/fields/") || strings.HasSuffix(path, "/heartbeat")):
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,48 @@
+package server
+
+import "testing"
+
+// TestDryRun validates that writes are validated but not made, while reads,
+// including those that POST, are made as usual.
+func TestDryRun(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.respondWithFile("GET", "/api/v1/secret-templates/6001", "secret-template.json")
+	f.respondWithFile("GET", "/api/v1/secrets/42", "secret.json")
+	f.respondWithFile("POST", "/api/v1/secrets/42/restricted", "secret.json")
+
+	tss := f.server()
+	tss.DryRun = true
+
+	secret, err := tss.CreateSecret(Secret{Name: "Test Secret", FolderID: 7, SecretTemplateID: 6001,
+		Fields: []SecretField{{Slug: "password", ItemValue: "Passw0rd."}}})
+	if err != nil {
+		t.Fatal("calling server.CreateSecret:", err)
+	}
+	if password, _ := secret.Field("password"); password != "Passw0rd." {
+		t.Errorf("expecting the secret that would have been created, but found %v", secret)
+	}
+	if _, err := tss.CreateSecret(Secret{Name: "Test Secret", SecretTemplateID: 6001,
+		Fields: []SecretField{{Slug: "nonexistent", ItemValue: "value"}}}); err == nil {
+		t.Error("expecting the secret to be validated")
+	}
+
+	if err := tss.DeleteSecret(42); err != nil {
+		t.Fatal("calling server.DeleteSecret:", err)
+	}
+	if _, err := tss.UpdateSecretField(42, "password", "Passw0rd.updated"); err != nil {
+		t.Fatal("calling server.UpdateSecretField:", err)
+	}
+	if _, err := tss.SecretWithComment(42, "deploying"); err != nil {
+		t.Fatal("calling server.SecretWithComment:", err)
+	}
+
+	validate("restricted reads", 1, f.count("POST", "/api/v1/secrets/42/restricted"), t)
+	for _, request := range [][2]string{{"POST", "/api/v1/secrets"}, {"DELETE", "/api/v1/secrets/42"},
+		{"PUT", "/api/v1/secrets/42/fields/password"}} {
+		if f.count(request[0], request[1]) != 0 {
+			t.Errorf("expecting no %s %s in a dry run", request[0], request[1])
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// exportVersion is the version of the format that ExportSecrets writes
+const exportVersion = 1
+
+// ExportedSecret is a secret as ExportSecrets writes it, with its folder,
+// template and site referred to by name rather than by id, so that it can be
+// imported into another Secret Server, where the ids differ
+type ExportedSecret struct {
+	Name, FolderPath, SecretTemplateName, SiteName string
+	Fields                                         []ExportedField
+}
+
+// ExportedField is the value of a field of an ExportedSecret, by its slug
+type ExportedField struct {
+	Slug, Value string
+}
+
+// secretExport is the document that ExportSecrets writes
+type secretExport struct {
+	Version int
+	Secrets []ExportedSecret
+}
+
+// ExportSecrets writes the secrets with the given ids to w as JSON, which
+// ImportSecrets can read back. The export holds the values of the secrets'
+// fields in the clear, so it must be stored as securely as the secrets
+// themselves. It does not hold their file attachments, their version history,
+// nor their permissions and settings other than their folder, template and
+// site.
+func (s Server) ExportSecrets(ids []int, w io.Writer) error {
+	export := secretExport{Version: exportVersion, Secrets: make([]ExportedSecret, 0, len(ids))}
+	folderPaths := map[int]string{-1: "", 0: ""}
+	templateNames := make(map[int]string)
+	siteNames := make(map[int]string)
+
+	sites, err := s.Sites()
+	if err != nil {
+		return err
+	}
+	for _, site := range sites {
+		siteNames[site.SiteID] = site.SiteName
+	}
+
+	for _, id := range ids {
+		secret, err := s.SecretMetadataOnly(id)
+		if err != nil {
+			return err
+		}
+
+		folderPath, found := folderPaths[secret.FolderID]
+		if !found {
+			folder, err := s.Folder(secret.FolderID)
+			if err != nil {
+				return err
+			}
+			folderPath = folder.FolderPath
+			folderPaths[secret.FolderID] = folderPath
+		}
+
+		templateName, found := templateNames[secret.SecretTemplateID]
+		if !found {
+			template, err := s.SecretTemplate(secret.SecretTemplateID)
+			if err != nil {
+				return err
+			}
+			templateName = template.Name
+			templateNames[secret.SecretTemplateID] = templateName
+		}
+
+		exported := ExportedSecret{
+			Name:               secret.Name,
+			FolderPath:         folderPath,
+			SecretTemplateName: templateName,
+			SiteName:           siteNames[secret.SiteID],
+			Fields:             make([]ExportedField, 0, len(secret.Fields)),
+		}
+		for _, field := range secret.Fields {
+			if !field.IsFile {
+				exported.Fields = append(exported.Fields, ExportedField{Slug: field.Slug, Value: field.ItemValue})
+			}
+		}
+		export.Secrets = append(export.Secrets, exported)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(export)
+}
+
+// ImportSecrets creates the secrets that ExportSecrets wrote to r with
+// CreateSecret, resolving their folders, templates and sites by name, and
+// returns their ids. A secret without a folder is created at the root, and
+// one without a site on the Local site. If a secret cannot be created, the ids
+// of those that were created before it are returned along with the error.
+func (s Server) ImportSecrets(r io.Reader) ([]int, error) {
+	var export secretExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("[ERROR] reading the secret export: %w", err)
+	}
+	if export.Version != exportVersion {
+		return nil, fmt.Errorf("[ERROR] unsupported secret export version '%d'", export.Version)
+	}
+
+	ids := make([]int, 0, len(export.Secrets))
+	folderIDs := map[string]int{"": -1}
+	templateIDs := make(map[string]int)
+	siteIDs := map[string]int{"": localSiteID}
+
+	for _, exported := range export.Secrets {
+		folderID, found := folderIDs[exported.FolderPath]
+		if !found {
+			id, err := s.FolderNameToID(exported.FolderPath)
+			if err != nil {
+				return ids, err
+			}
+			folderID = id
+			folderIDs[exported.FolderPath] = folderID
+		}
+
+		templateID, found := templateIDs[exported.SecretTemplateName]
+		if !found {
+			id, err := s.SecretTemplateNameToID(exported.SecretTemplateName)
+			if err != nil {
+				return ids, err
+			}
+			templateID = id
+			templateIDs[exported.SecretTemplateName] = templateID
+		}
+
+		siteID, found := siteIDs[exported.SiteName]
+		if !found {
+			id, err := s.SiteNameToID(exported.SiteName)
+			if err != nil {
+				return ids, err
+			}
+			siteID = id
+			siteIDs[exported.SiteName] = siteID
+		}
+
+		secret := Secret{
+			Name:             exported.Name,
+			FolderID:         folderID,
+			SecretTemplateID: templateID,
+			SiteID:           siteID,
+			Fields:           make([]SecretField, 0, len(exported.Fields)),
+		}
+		for _, field := range exported.Fields {
+			secret.Fields = append(secret.Fields, SecretField{Slug: field.Slug, ItemValue: field.Value})
+		}
+
+		created, err := s.CreateSecret(secret)
+		if err != nil {
+			return ids, fmt.Errorf("[ERROR] importing the secret named '%s': %w", exported.Name, err)
+		}
+		ids = append(ids, created.ID)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestExportAndImportSecrets validates that an exported secret refers to its
+// folder, template and site by name, and that importing it resolves those
+// names and creates the secret with the exported field values.
+func TestExportAndImportSecrets(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.respondWithFile("GET", "/api/v1/secrets/42", "secret.json")
+	f.respondWithFile("GET", "/api/v1/secret-templates/6001", "secret-template.json")
+	f.respond("GET", "/api/v1/secret-templates", http.StatusOK, `{"records": [{"id": 6001, "name": "Password"}]}`)
+	f.respond("GET", "/api/v1/folders/7", http.StatusOK, `{"id": 7, "folderName": "Prod", "folderPath": "\\Engineering\\Prod"}`)
+	f.respond("GET", "/api/v1/folders", http.StatusOK, `{"records": [{"id": 7, "folderName": "Prod", "folderPath": "\\Engineering\\Prod"}]}`)
+	f.respond("GET", "/api/v1/sites", http.StatusOK, `{"records": [{"siteId": 1, "siteName": "Local", "active": true}]}`)
+
+	var created Secret
+	f.handle("POST", "/api/v1/secrets", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&created); err != nil {
+			t.Error("decoding the create request body:", err)
+		}
+		fmt.Fprint(w, `{"ID": 42}`)
+	})
+
+	tss := f.server()
+	var export bytes.Buffer
+	if err := tss.ExportSecrets([]int{42}, &export); err != nil {
+		t.Fatal("calling server.ExportSecrets:", err)
+	}
+	for _, expected := range []string{`"FolderPath": "\\Engineering\\Prod"`, `"SecretTemplateName": "Password"`, `"SiteName": "Local"`} {
+		if !strings.Contains(export.String(), expected) {
+			t.Errorf("expecting the export to contain %s, but found:\n%s", expected, export.String())
+		}
+	}
+
+	ids, err := tss.ImportSecrets(&export)
+	if err != nil {
+		t.Fatal("calling server.ImportSecrets:", err)
+	}
+	if !validate("imported ids", "[42]", fmt.Sprint(ids), t) ||
+		!validate("folder id", 7, created.FolderID, t) ||
+		!validate("template id", 6001, created.SecretTemplateID, t) ||
+		!validate("site id", 1, created.SiteID, t) {
+		return
+	}
+	if password, _ := created.Field("password"); !validate("password", "Passw0rd.", password, t) {
+		return
+	}
+
+	if _, err := tss.ImportSecrets(strings.NewReader(`{"Version": 2, "Secrets": []}`)); err == nil {
+		t.Error("expecting an error importing an unsupported version")
+	}
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fixture is a stand-in for Secret Server that replays recorded responses, so
+// that the request handling of the SDK can be tested without a live server.
+type fixture struct {
+	*httptest.Server
+	t        *testing.T
+	mutex    sync.Mutex
+	handlers map[string]http.HandlerFunc
+	calls    map[string]int
+}
+
+// newFixture starts a fixture that grants an access token to any client and
+// responds with a 404 to any request that it has no handler for. The caller
+// must Close the fixture when done.
+func newFixture(t *testing.T) *fixture {
+	f := newUnstartedFixture(t)
+	f.Start()
+	return f
+}
+
+// newTLSFixture is newFixture for a fixture that serves HTTPS with a
+// certificate that is not signed by any of the system's trusted CAs
+func newTLSFixture(t *testing.T) *fixture {
+	f := newUnstartedFixture(t)
+	f.StartTLS()
+	return f
+}
+
+func newUnstartedFixture(t *testing.T) *fixture {
+	f := &fixture{
+		t:        t,
+		handlers: make(map[string]http.HandlerFunc),
+		calls:    make(map[string]int),
+	}
+	f.Server = httptest.NewUnstartedServer(http.HandlerFunc(f.serveHTTP))
+	f.respond("POST", "/oauth2/token", http.StatusOK,
+		`{"access_token":"fixture-token","token_type":"bearer","expires_in":1200}`)
+	return f
+}
+
+func (f *fixture) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	// the SDK addresses collections with a trailing slash, e.g. POST /secrets/
+	key := r.Method + " " + strings.TrimSuffix(r.URL.Path, "/")
+
+	f.mutex.Lock()
+	f.calls[key]++
+	handler, found := f.handlers[key]
+	f.mutex.Unlock()
+
+	if !found {
+		http.Error(w, fmt.Sprintf("no fixture for %s", key), http.StatusNotFound)
+		return
+	}
+	handler(w, r)
+}
+
+// handle registers the handler for requests with the given method and path
+func (f *fixture) handle(method, path string, handler http.HandlerFunc) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.handlers[method+" "+path] = handler
+}
+
+// respond registers a handler that responds with the given status and body
+func (f *fixture) respond(method, path string, status int, body string) {
+	f.handle(method, path, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	})
+}
+
+// respondWithFile registers a handler that responds with the contents of the
+// named file in the testdata directory
+func (f *fixture) respondWithFile(method, path, name string) {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		f.t.Fatalf("reading fixture '%s': %s", name, err)
+	}
+	f.respond(method, path, http.StatusOK, string(data))
+}
+
+// count returns the number of requests received with the given method and path
+func (f *fixture) count(method, path string) int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.calls[method+" "+path]
+}
+
+// server returns a Server configured to call the fixture
+func (f *fixture) server() *Server {
+	tss, err := New(Configuration{
+		Credentials: UserCredential{Username: "fixture-user", Password: "fixture-password"},
+		ServerURL:   f.URL,
+	})
+	if err != nil {
+		f.t.Fatal("configuring the Server:", err)
+	}
+	return tss
+}
@@ -0,0 +1,302 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// folderResource is the HTTP URL path component for the folders resource
+const folderResource = "folders"
+
+// folderPermissionResource is the HTTP URL path component for the folder
+// permissions resource
+const folderPermissionResource = "folder-permissions"
+
+// defaultFolderTypeID is the type of folder that is created when none is given
+const defaultFolderTypeID = 1
+
+// Folder represents a folder from Delinea Secret Server
+type Folder struct {
+	FolderName, FolderPath                           string
+	ID, ParentFolderID, FolderTypeID, SecretPolicyID int
+	InheritPermissions, InheritSecretPolicy          bool
+}
+
+// FolderPermission grants a user or a group, identified by UserID or GroupID,
+// access to a folder and to the secrets in it, under the named roles, e.g.
+// View, Edit or Owner
+type FolderPermission struct {
+	ID, FolderID, GroupID, UserID              int
+	GroupName, UserName                        string
+	FolderAccessRoleName, SecretAccessRoleName string
+	// Inherited is true if the permission is set on an ancestor of the folder,
+	// with the id InheritedFromFolderID, rather than on the folder itself. It
+	// is only set by FolderPermissions when it includes inherited permissions.
+	Inherited             bool `json:"-"`
+	InheritedFromFolderID int  `json:"-"`
+}
+
+// FolderNotFoundError is returned when no folder has the given path
+type FolderNotFoundError struct {
+	Path string
+}
+
+func (e *FolderNotFoundError) Error() string {
+	return fmt.Sprintf("no folder with path '%s'", e.Path)
+}
+
+// MultipleFoldersFoundError is returned when more than one folder has the
+// given path, e.g. because folder paths are compared ignoring case. IDs are
+// the candidate folders in the order that the server returned them, and Path
+// is the path as it was searched for.
+type MultipleFoldersFoundError struct {
+	IDs  []int
+	Path string
+}
+
+func (e *MultipleFoldersFoundError) Error() string {
+	return fmt.Sprintf("%d folders with path '%s': %v", len(e.IDs), e.Path, e.IDs)
+}
+
+// FolderResolutionError is returned by CreateSecretInPath when the folder with
+// the given path could not be found, or created, with Err saying why, e.g. a
+// *FolderNotFoundError
+type FolderResolutionError struct {
+	Path string
+	Err  error
+}
+
+func (e *FolderResolutionError) Error() string {
+	return fmt.Sprintf("resolving the folder path '%s': %s", e.Path, e.Err)
+}
+
+func (e *FolderResolutionError) Unwrap() error {
+	return e.Err
+}
+
+// Folder gets the folder with id from the Secret Server of the given tenant
+func (s Server) Folder(id int) (*Folder, error) {
+	folder := new(Folder)
+
+	if data, err := s.accessResource("GET", folderResource, strconv.Itoa(id), nil); err == nil {
+		if err = s.unmarshal(data, folder); err != nil {
+			s.logger().Errorf("error parsing response from /%s/%d: %s", folderResource, id, redactBody(data))
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
+	return folder, nil
+}
+
+// CreateFolder creates a folder named FolderName in the folder with the ID
+// ParentFolderID, or at the root if it is -1, and returns the folder as it was
+// stored by the server, including its server-assigned ID.
+func (s Server) CreateFolder(folder Folder) (*Folder, error) {
+	if strings.TrimSpace(folder.FolderName) == "" {
+		return nil, fmt.Errorf("[ERROR] the folder name must not be empty")
+	}
+	if folder.FolderTypeID == 0 {
+		folder.FolderTypeID = defaultFolderTypeID
+	}
+
+	createdFolder := new(Folder)
+
+	if data, err := s.accessResource("POST", folderResource, "/", folder); err == nil {
+		if err = s.unmarshal(data, createdFolder); err != nil {
+			s.logger().Errorf("error parsing response from /%s: %s", folderResource, redactBody(data))
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
+	return createdFolder, nil
+}
+
+// DeleteFolder deletes the folder with the given id
+func (s Server) DeleteFolder(id int) error {
+	_, err := s.accessResource("DELETE", folderResource, strconv.Itoa(id), nil)
+	return err
+}
+
+// FolderNameToID returns the ID of the folder with the given path, e.g.
+// \Engineering\Prod, which may be separated by either \ or /, ignoring case.
+// It returns a *FolderNotFoundError if there is no such folder and a
+// *MultipleFoldersFoundError if there is more than one.
+func (s Server) FolderNameToID(path string) (int, error) {
+	folderPath := normalizeFolderPath(path)
+	if folderPath == `\` {
+		return 0, fmt.Errorf("[ERROR] the folder path must name a folder")
+	}
+	folderName := folderPath[strings.LastIndex(folderPath, `\`)+1:]
+
+	ids := make([]int, 0)
+	query := url.Values{"paging.filter.searchText": {folderName}}
+	err := s.eachPage(context.Background(), folderResource, "", query, func(record json.RawMessage) error {
+		var folder Folder
+		if err := json.Unmarshal(record, &folder); err != nil {
+			return err
+		}
+		if strings.EqualFold(normalizeFolderPath(folder.FolderPath), folderPath) {
+			ids = append(ids, folder.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	switch len(ids) {
+	case 0:
+		return 0, &FolderNotFoundError{Path: path}
+	case 1:
+		return ids[0], nil
+	default:
+		return 0, &MultipleFoldersFoundError{IDs: ids, Path: path}
+	}
+}
+
+// ensureFolderPath returns the ID of the folder with the given path, creating
+// it, and any of its parents that are missing, if it does not exist. The root,
+// \, has the ID -1.
+func (s Server) ensureFolderPath(path string) (int, error) {
+	folderPath := normalizeFolderPath(path)
+	if folderPath == `\` {
+		return -1, nil
+	}
+	id, err := s.FolderNameToID(folderPath)
+	if _, notFound := err.(*FolderNotFoundError); !notFound {
+		return id, err
+	}
+
+	parentID, parentPath, missing := -1, "", false
+	for _, name := range strings.Split(folderPath[1:], `\`) {
+		parentPath += `\` + name
+		if !missing {
+			id, err := s.FolderNameToID(parentPath)
+			if err == nil {
+				parentID = id
+				continue
+			}
+			if _, notFound := err.(*FolderNotFoundError); !notFound {
+				return 0, err
+			}
+			// the folders below a missing one are missing too
+			missing = true
+		}
+
+		folder, err := s.CreateFolder(Folder{FolderName: name, ParentFolderID: parentID})
+		if err != nil {
+			return 0, err
+		}
+		s.logger().Debugf("created the folder '%s' with id %d", parentPath, folder.ID)
+		parentID = folder.ID
+	}
+
+	return parentID, nil
+}
+
+// normalizeFolderPath returns the given folder path separated by \, with a
+// leading \ and without a trailing one
+func normalizeFolderPath(path string) string {
+	path = strings.Trim(strings.ReplaceAll(path, "/", `\`), `\`)
+	return `\` + path
+}
+
+// FolderSecrets returns the summaries of the secrets in the folder with the
+// given id, and if includeSubfolders is true, of those in its subfolders too.
+// It fetches as many pages of results as it takes, but none of the secrets'
+// fields; get those with Secret as needed.
+func (s Server) FolderSecrets(folderID int, includeSubfolders bool) ([]SecretSummary, error) {
+	filter := url.Values{
+		"paging.filter.folderId":          {strconv.Itoa(folderID)},
+		"paging.filter.includeSubFolders": {strconv.FormatBool(includeSubfolders)},
+	}
+	return s.searchAllSecretSummaries(context.Background(), filter)
+}
+
+// FolderPermissions returns the permissions that are set on the folder with
+// the given id, and if includeInherited is true, those that it inherits too,
+// i.e. the effective permissions of the folder. These are the permissions set
+// on each of its ancestors, up to the first one that does not inherit
+// permissions from its parent, and have Inherited set.
+func (s Server) FolderPermissions(folderID int, includeInherited bool) ([]FolderPermission, error) {
+	permissions, err := s.folderPermissions(folderID)
+	if err != nil || !includeInherited {
+		return permissions, err
+	}
+
+	visited := map[int]bool{folderID: true}
+	for id := folderID; ; {
+		folder, err := s.Folder(id)
+		if err != nil {
+			return nil, err
+		}
+		if !folder.InheritPermissions || folder.ParentFolderID <= 0 || visited[folder.ParentFolderID] {
+			return permissions, nil
+		}
+		id = folder.ParentFolderID
+		visited[id] = true
+
+		inherited, err := s.folderPermissions(id)
+		if err != nil {
+			return nil, err
+		}
+		for _, permission := range inherited {
+			permission.Inherited = true
+			permission.InheritedFromFolderID = id
+			permissions = append(permissions, permission)
+		}
+	}
+}
+
+// folderPermissions returns the permissions that are set on the folder with
+// the given id itself
+func (s Server) folderPermissions(folderID int) ([]FolderPermission, error) {
+	permissions := make([]FolderPermission, 0)
+	query := url.Values{"paging.filter.folderId": {strconv.Itoa(folderID)}}
+
+	err := s.eachPage(context.Background(), folderPermissionResource, "", query, func(record json.RawMessage) error {
+		var permission FolderPermission
+		if err := json.Unmarshal(record, &permission); err != nil {
+			return err
+		}
+		permissions = append(permissions, permission)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+// AddFolderPermission grants the user or group of the given permission access
+// to the folder with the given id, and returns the permission that was stored
+func (s Server) AddFolderPermission(folderID int, permission FolderPermission) (*FolderPermission, error) {
+	if permission.UserID == 0 && permission.GroupID == 0 {
+		return nil, fmt.Errorf("[ERROR] the folder permission must have a user id or a group id")
+	}
+	if permission.FolderAccessRoleName == "" {
+		return nil, fmt.Errorf("[ERROR] the folder permission must have a folder access role")
+	}
+	permission.FolderID = folderID
+
+	addedPermission := new(FolderPermission)
+
+	if data, err := s.accessResource("POST", folderPermissionResource, "/", permission); err == nil {
+		if err = s.unmarshal(data, addedPermission); err != nil {
+			s.logger().Errorf("error parsing response from /%s: %s", folderPermissionResource, redactBody(data))
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
+	return addedPermission, nil
+}
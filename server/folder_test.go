@@ -0,0 +1,229 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// TestFolder validates that a folder is read and parsed.
+func TestFolder(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.respond("GET", "/api/v1/folders/7", http.StatusOK, `{"id": 7, "folderName": "Prod", "folderPath": "\\Engineering\\Prod",
+		"parentFolderId": 3, "folderTypeId": 1, "secretPolicyId": -1, "inheritPermissions": true, "inheritSecretPolicy": true}`)
+
+	folder, err := f.server().Folder(7)
+	if err != nil {
+		t.Fatal("calling server.Folder:", err)
+	}
+	if !validate("folder name", "Prod", folder.FolderName, t) ||
+		!validate("folder path", `\Engineering\Prod`, folder.FolderPath, t) ||
+		!validate("parent folder id", 3, folder.ParentFolderID, t) ||
+		!validate("folder inherits permissions", true, folder.InheritPermissions, t) {
+		return
+	}
+}
+
+// TestFolderNameToID validates that a folder path resolves to the one folder
+// with that exact path, and that missing and ambiguous paths are reported.
+func TestFolderNameToID(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.handle("GET", "/api/v1/folders", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("paging.filter.searchText") {
+		case "Prod":
+			fmt.Fprint(w, `{"records": [{"id": 7, "folderName": "Prod", "folderPath": "\\Engineering\\Prod"},
+				{"id": 8, "folderName": "Prod", "folderPath": "\\Finance\\Prod"},
+				{"id": 9, "folderName": "Prod-Old", "folderPath": "\\Engineering\\Prod-Old"}]}`)
+		case "Shared":
+			fmt.Fprint(w, `{"records": [{"id": 10, "folderName": "Shared", "folderPath": "\\Shared"},
+				{"id": 11, "folderName": "Shared", "folderPath": "\\shared"}]}`)
+		default:
+			fmt.Fprint(w, `{"records": []}`)
+		}
+	})
+
+	tss := f.server()
+	for _, path := range []string{`\Engineering\Prod`, "/Engineering/Prod/", `Engineering\Prod`} {
+		id, err := tss.FolderNameToID(path)
+		if err != nil {
+			t.Errorf("calling server.FolderNameToID with '%s': %s", path, err)
+		} else if !validate("folder id of "+path, 7, id, t) {
+			return
+		}
+	}
+
+	_, err := tss.FolderNameToID(`\Engineering\Staging`)
+	var notFound *FolderNotFoundError
+	if !errors.As(err, &notFound) {
+		t.Errorf("expecting a *FolderNotFoundError, but found '%v' instead", err)
+	}
+
+	_, err = tss.FolderNameToID("/Shared")
+	var multiple *MultipleFoldersFoundError
+	if !errors.As(err, &multiple) || len(multiple.IDs) != 2 {
+		t.Errorf("expecting a *MultipleFoldersFoundError with 2 ids, but found '%v' instead", err)
+	}
+}
+
+// TestFolderSecrets validates that the secrets in a folder are listed across
+// pages of results, optionally including those in its subfolders.
+func TestFolderSecrets(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.handle("GET", "/api/v1/secrets", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("paging.filter.folderId") != "7" {
+			t.Errorf("expecting the folder id filter to be 7, but found '%s' instead", query.Get("paging.filter.folderId"))
+		}
+		switch {
+		case query.Get("paging.filter.includeSubFolders") != "true":
+			fmt.Fprint(w, `{"records": [{"id": 42, "name": "app-user", "folderId": 7}], "hasNext": false}`)
+		case query.Get("paging.skip") == "0":
+			fmt.Fprint(w, `{"records": [{"id": 42, "name": "app-user", "folderId": 7}], "hasNext": true}`)
+		default:
+			fmt.Fprint(w, `{"records": [{"id": 43, "name": "db-user", "folderId": 12}], "hasNext": false}`)
+		}
+	})
+
+	tss := f.server()
+	summaries, err := tss.FolderSecrets(7, false)
+	if err != nil {
+		t.Fatal("calling server.FolderSecrets:", err)
+	}
+	if len(summaries) != 1 || !validate("secret name", "app-user", summaries[0].Name, t) {
+		return
+	}
+
+	summaries, err = tss.FolderSecrets(7, true)
+	if err != nil {
+		t.Fatal("calling server.FolderSecrets:", err)
+	}
+	if len(summaries) != 2 || !validate("subfolder secret id", 43, summaries[1].ID, t) {
+		t.Errorf("expecting 2 secrets, but found %v instead", summaries)
+	}
+}
+
+// TestCreateFolderWithoutName validates that a folder without a name is
+// rejected before it is sent to the server.
+func TestCreateFolderWithoutName(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	if _, err := f.server().CreateFolder(Folder{ParentFolderID: 3}); err == nil {
+		t.Error("expecting an error creating a folder without a name")
+	}
+	if f.count("POST", "/api/v1/folders") != 0 {
+		t.Error("expecting the folder without a name to be rejected before it was sent to the server")
+	}
+}
+
+// TestFolderPermissionsIncludingInherited validates that the permissions that
+// a folder inherits are included, and marked, only when asked for, and only
+// from the ancestors that it inherits from.
+func TestFolderPermissionsIncludingInherited(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.respond("GET", "/api/v1/folders/7", http.StatusOK, `{"id": 7, "parentFolderId": 3, "inheritPermissions": true}`)
+	f.respond("GET", "/api/v1/folders/3", http.StatusOK, `{"id": 3, "parentFolderId": 1, "inheritPermissions": false}`)
+	f.handle("GET", "/api/v1/folder-permissions", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("paging.filter.folderId") {
+		case "7":
+			w.Write([]byte(`{"records": [{"id": 70, "folderId": 7, "userId": 10, "folderAccessRoleName": "Edit"}]}`))
+		case "3":
+			w.Write([]byte(`{"records": [{"id": 30, "folderId": 3, "groupId": 20, "folderAccessRoleName": "View"}]}`))
+		default:
+			t.Errorf("unexpected request for the permissions of folder %s", r.URL.Query().Get("paging.filter.folderId"))
+		}
+	})
+
+	tss := f.server()
+	permissions, err := tss.FolderPermissions(7, false)
+	if err != nil {
+		t.Fatal("calling server.FolderPermissions:", err)
+	}
+	if len(permissions) != 1 || permissions[0].Inherited {
+		t.Errorf("expecting only the explicit permission, but found %+v", permissions)
+	}
+
+	permissions, err = tss.FolderPermissions(7, true)
+	if err != nil {
+		t.Fatal("calling server.FolderPermissions:", err)
+	}
+	if !validate("permissions", 2, len(permissions), t) {
+		return
+	}
+	validate("explicit permission inherited", false, permissions[0].Inherited, t)
+	validate("inherited permission inherited", true, permissions[1].Inherited, t)
+	validate("inherited from folder id", 3, permissions[1].InheritedFromFolderID, t)
+	validate("inherited group id", 20, permissions[1].GroupID, t)
+}
+
+// TestFolderPermissionsPaged validates that every page of a folder's
+// permissions is read.
+func TestFolderPermissionsPaged(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.handle("GET", "/api/v1/folder-permissions", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("paging.skip") {
+		case "0":
+			w.Write([]byte(`{"records": [{"id": 70, "folderId": 7, "userId": 10, "folderAccessRoleName": "Edit"}], "hasNext": true}`))
+		case "1":
+			w.Write([]byte(`{"records": [{"id": 71, "folderId": 7, "groupId": 20, "folderAccessRoleName": "View"}], "hasNext": false}`))
+		default:
+			t.Errorf("unexpected request for the page at %s", r.URL.Query().Get("paging.skip"))
+		}
+	})
+
+	permissions, err := f.server().FolderPermissions(7, false)
+	if err != nil {
+		t.Fatal("calling server.FolderPermissions:", err)
+	}
+	if !validate("permissions", 2, len(permissions), t) {
+		return
+	}
+	validate("second permission id", 71, permissions[1].ID, t)
+}
+
+// TestAddFolderPermission validates that the permission is posted for the
+// folder, and that one without a user or group, or without a role, is
+// rejected without calling the server.
+func TestAddFolderPermission(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.handle("POST", "/api/v1/folder-permissions", func(w http.ResponseWriter, r *http.Request) {
+		var input FolderPermission
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			t.Error("parsing the folder permission:", err)
+		}
+		validate("folder id", 7, input.FolderID, t)
+		validate("group id", 20, input.GroupID, t)
+		validate("folder access role", "View", input.FolderAccessRoleName, t)
+		validate("secret access role", "List", input.SecretAccessRoleName, t)
+		w.Write([]byte(`{"id": 71, "folderId": 7, "groupId": 20, "folderAccessRoleName": "View", "secretAccessRoleName": "List"}`))
+	})
+
+	tss := f.server()
+	permission, err := tss.AddFolderPermission(7, FolderPermission{GroupID: 20, FolderAccessRoleName: "View", SecretAccessRoleName: "List"})
+	if err != nil {
+		t.Fatal("calling server.AddFolderPermission:", err)
+	}
+	validate("permission id", 71, permission.ID, t)
+
+	if _, err := tss.AddFolderPermission(7, FolderPermission{FolderAccessRoleName: "View"}); err == nil {
+		t.Error("expecting an error adding a permission without a user or a group")
+	}
+	if _, err := tss.AddFolderPermission(7, FolderPermission{UserID: 10}); err == nil {
+		t.Error("expecting an error adding a permission without a folder access role")
+	}
+	validate("permission requests", 1, f.count("POST", "/api/v1/folder-permissions"), t)
+}
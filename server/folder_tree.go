@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+)
+
+// defaultFolderTreeMaxDepth is how many levels of subfolders FolderTree
+// descends into
+const defaultFolderTreeMaxDepth = 32
+
+// FolderNode is a folder in the tree that FolderTree returns, with its
+// subfolders and, if they were asked for, the summaries of its secrets
+type FolderNode struct {
+	Folder
+	Children []*FolderNode
+	Secrets  []SecretSummary
+	// Truncated is true when the folder has subfolders that were left out
+	// because the tree reached its MaxDepth
+	Truncated bool
+}
+
+// FolderTreeOptions control how much of the tree FolderTreeWithOptions builds
+type FolderTreeOptions struct {
+	// MaxDepth is how many levels of subfolders are descended into below the
+	// root. It defaults to 32.
+	MaxDepth int
+	// IncludeSecrets fetches the summaries of the secrets in each folder
+	IncludeSecrets bool
+}
+
+// FolderTree returns the folder with the given id and all of its subfolders,
+// down to 32 levels below it, without their secrets
+func (s Server) FolderTree(rootID int) (*FolderNode, error) {
+	return s.FolderTreeWithOptions(rootID, FolderTreeOptions{})
+}
+
+// FolderTreeWithOptions is FolderTree controlled by the given options. A
+// folder that appears more than once in the tree, which could only happen if
+// the server reported a cycle, is only descended into the first time.
+func (s Server) FolderTreeWithOptions(rootID int, options FolderTreeOptions) (*FolderNode, error) {
+	if options.MaxDepth <= 0 {
+		options.MaxDepth = defaultFolderTreeMaxDepth
+	}
+
+	root, err := s.Folder(rootID)
+	if err != nil {
+		return nil, err
+	}
+
+	node := &FolderNode{Folder: *root}
+	visited := map[int]bool{root.ID: true}
+	if err := s.buildFolderTree(node, 0, options, visited); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// buildFolderTree adds the subfolders, and if the options ask for them the
+// secrets, of the folder of the given node, which is at the given depth
+func (s Server) buildFolderTree(node *FolderNode, depth int, options FolderTreeOptions, visited map[int]bool) error {
+	if options.IncludeSecrets {
+		secrets, err := s.FolderSecrets(node.ID, false)
+		if err != nil {
+			return err
+		}
+		node.Secrets = secrets
+	}
+
+	children, err := s.subfolders(node.ID)
+	if err != nil {
+		return err
+	}
+	if depth >= options.MaxDepth {
+		node.Truncated = len(children) > 0
+		return nil
+	}
+
+	for _, child := range children {
+		if visited[child.ID] {
+			s.logger().Debugf("folder %d is already in the tree, skipping it under folder %d", child.ID, node.ID)
+			continue
+		}
+		visited[child.ID] = true
+
+		childNode := &FolderNode{Folder: child}
+		if err := s.buildFolderTree(childNode, depth+1, options, visited); err != nil {
+			return err
+		}
+		node.Children = append(node.Children, childNode)
+	}
+
+	return nil
+}
+
+// subfolders returns the folders directly in the folder with the given id
+func (s Server) subfolders(parentID int) ([]Folder, error) {
+	folders := make([]Folder, 0)
+	query := url.Values{"paging.filter.parentFolderId": {strconv.Itoa(parentID)}}
+
+	err := s.eachPage(context.Background(), folderResource, "", query, func(record json.RawMessage) error {
+		var folder Folder
+		if err := json.Unmarshal(record, &folder); err != nil {
+			return err
+		}
+		// only keep the direct children, in case the server also returns
+		// deeper descendants
+		if folder.ParentFolderID == parentID {
+			folders = append(folders, folder)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return folders, nil
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestFolderTree validates that the tree holds every subfolder once, even if
+// the server reports a cycle, and that it stops at the MaxDepth.
+func TestFolderTree(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	// folder 4 claims folder 1, the root, as its child
+	children := map[int][]int{1: {2, 3}, 2: {4}, 4: {1}}
+	folder := func(id, parentID int) string {
+		return fmt.Sprintf(`{"id": %d, "folderName": "Folder %d", "parentFolderId": %d}`, id, id, parentID)
+	}
+	f.respond("GET", "/api/v1/folders/1", http.StatusOK, folder(1, -1))
+	f.handle("GET", "/api/v1/folders", func(w http.ResponseWriter, r *http.Request) {
+		parentID, _ := strconv.Atoi(r.URL.Query().Get("paging.filter.parentFolderId"))
+		records := make([]string, 0)
+		for _, id := range children[parentID] {
+			records = append(records, folder(id, parentID))
+		}
+		fmt.Fprintf(w, `{"records": [%s]}`, strings.Join(records, ", "))
+	})
+
+	tss := f.server()
+	tree, err := tss.FolderTree(1)
+	if err != nil {
+		t.Fatal("calling server.FolderTree:", err)
+	}
+	if len(tree.Children) != 2 || len(tree.Children[0].Children) != 1 {
+		t.Fatalf("expecting folders 2 and 3 under the root and 4 under 2, but found %+v", tree)
+	}
+	if grandchild := tree.Children[0].Children[0]; grandchild.ID != 4 || len(grandchild.Children) != 0 {
+		t.Errorf("expecting folder 4 without the root under it, but found %+v", grandchild)
+	}
+
+	tree, err = tss.FolderTreeWithOptions(1, FolderTreeOptions{MaxDepth: 1})
+	if err != nil {
+		t.Fatal("calling server.FolderTreeWithOptions:", err)
+	}
+	if child := tree.Children[0]; len(child.Children) != 0 || !child.Truncated {
+		t.Errorf("expecting folder 2 to be truncated, but found %+v", child)
+	}
+	if child := tree.Children[1]; child.Truncated {
+		t.Errorf("expecting folder 3, which has no subfolders, not to be truncated")
+	}
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// HeartbeatState is the outcome of the last heartbeat of a secret
+type HeartbeatState string
+
+// The states that a heartbeat can end in
+const (
+	HeartbeatSuccess          HeartbeatState = "Success"
+	HeartbeatFailed           HeartbeatState = "Failed"
+	HeartbeatPending          HeartbeatState = "Pending"
+	HeartbeatProcessing       HeartbeatState = "Processing"
+	HeartbeatDisabled         HeartbeatState = "Disabled"
+	HeartbeatUnableToConnect  HeartbeatState = "UnableToConnect"
+	HeartbeatUnknownError     HeartbeatState = "UnknownError"
+	HeartbeatIncompatibleHost HeartbeatState = "IncompatibleHost"
+	HeartbeatAccountLockedOut HeartbeatState = "AccountLockedOut"
+	HeartbeatArgumentError    HeartbeatState = "ArgumentError"
+	HeartbeatAccessDenied     HeartbeatState = "AccessDenied"
+)
+
+// HeartbeatStatus is the status of the heartbeat of a secret, which checks
+// that its credentials still work against the system they are for
+type HeartbeatStatus struct {
+	LastHeartBeatStatus HeartbeatState
+	LastHeartBeatCheck  Time
+	Message             string
+}
+
+// Succeeded reports whether the last heartbeat succeeded
+func (h HeartbeatStatus) Succeeded() bool {
+	return h.LastHeartBeatStatus == HeartbeatSuccess
+}
+
+// RunHeartbeat runs the heartbeat of the secret with the given id and returns
+// its status
+func (s Server) RunHeartbeat(id int) (*HeartbeatStatus, error) {
+	status := new(HeartbeatStatus)
+	path := fmt.Sprintf("%d/heartbeat", id)
+
+	if data, err := s.accessResource("POST", resource, path, nil); err == nil {
+		if err = json.Unmarshal(data, status); err != nil {
+			s.logger().Errorf("error parsing response from /%s/%s: %s", resource, path, redactBody(data))
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
+	return status, nil
+}
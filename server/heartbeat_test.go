@@ -0,0 +1,49 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// TestRunHeartbeat validates that each status that the server reports maps to
+// its HeartbeatState, that an unknown one is kept as it is, and that only a
+// success is reported as one.
+func TestRunHeartbeat(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	var status string
+	f.handle("POST", "/api/v1/secrets/42/heartbeat", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"lastHeartBeatStatus": "%s", "lastHeartBeatCheck": "2026-03-01T08:30:00", "message": "checked"}`, status)
+	})
+
+	tss := f.server()
+	for _, test := range []struct {
+		status    string
+		state     HeartbeatState
+		succeeded bool
+	}{
+		{"Success", HeartbeatSuccess, true},
+		{"Failed", HeartbeatFailed, false},
+		{"Pending", HeartbeatPending, false},
+		{"Processing", HeartbeatProcessing, false},
+		{"Disabled", HeartbeatDisabled, false},
+		{"UnableToConnect", HeartbeatUnableToConnect, false},
+		{"UnknownError", HeartbeatUnknownError, false},
+		{"IncompatibleHost", HeartbeatIncompatibleHost, false},
+		{"AccountLockedOut", HeartbeatAccountLockedOut, false},
+		{"ArgumentError", HeartbeatArgumentError, false},
+		{"AccessDenied", HeartbeatAccessDenied, false},
+		{"SomethingNew", HeartbeatState("SomethingNew"), false},
+	} {
+		status = test.status
+		heartbeat, err := tss.RunHeartbeat(42)
+		if err != nil {
+			t.Fatal("calling server.RunHeartbeat:", err)
+		}
+		validate("state for "+test.status, test.state, heartbeat.LastHeartBeatStatus, t)
+		validate("succeeded for "+test.status, test.succeeded, heartbeat.Succeeded(), t)
+		validate("last check year for "+test.status, 2026, heartbeat.LastHeartBeatCheck.Year(), t)
+	}
+}
@@ -1,18 +1,125 @@
 package server
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"math/rand"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 )
 
 const errorBodyLength = 255
 
+// maxRetryDelay is the longest that a request waits before it is retried,
+// however many attempts it has made, or however long the server asks for
+const maxRetryDelay = 30 * time.Second
+
+// ResponseInfo describes the outcome of an attempt at a request to the API
+type ResponseInfo struct {
+	Method, Resource, Path string
+	// StatusCode is the HTTP status of the response, or 0 if there was none,
+	// e.g. because of a network error
+	StatusCode int
+	Duration   time.Duration
+	// Err is the error that the attempt failed with, if any
+	Err error
+}
+
+// APIError is returned when the server responds with a status other than 2xx
+type APIError struct {
+	// StatusCode and Status are the HTTP status of the response
+	StatusCode int
+	Status     string
+	// ErrorCode and Message are taken from the JSON error that the server
+	// returns, Message being the whole body if it is not JSON
+	ErrorCode, Message string
+	// Body is the body of the response
+	Body []byte
+}
+
+func (e *APIError) Error() string {
+	data := e.Body
+
+	// truncate the data to errorBodyLength bytes before returning it as part of the error
+	if len(data) >= errorBodyLength {
+		data = append(data[:errorBodyLength:errorBodyLength], []byte("...")...)
+	}
+
+	return fmt.Sprintf("%s: %s", e.Status, string(data))
+}
+
+// newAPIError returns the APIError for the given response with the given body
+func newAPIError(res *http.Response, data []byte) *APIError {
+	apiError := &APIError{StatusCode: res.StatusCode, Status: res.Status, Body: data}
+
+	body := struct{ ErrorCode, Message string }{}
+	if json.Unmarshal(data, &body) == nil && body.Message != "" {
+		apiError.ErrorCode = body.ErrorCode
+		apiError.Message = body.Message
+	} else {
+		apiError.Message = string(data)
+	}
+
+	return apiError
+}
+
+// NotFoundError is returned when the server responds that the resource at the
+// given path does not exist
+type NotFoundError struct {
+	Resource, Path string
+	err            error
+}
+
+func (e *NotFoundError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the error that reported the response, which is an *APIError
+func (e *NotFoundError) Unwrap() error {
+	return e.err
+}
+
+// RequestError is returned when a request to the API fails, with the Method
+// and the URL of the request, so that it can be reproduced, and the Err that
+// it failed with, e.g. an *APIError or a *NotFoundError. The URL is redacted
+// like logged bodies are; the access token is sent in a header, so it is not
+// part of it.
+type RequestError struct {
+	Method, URL string
+	Err         error
+}
+
+func (e *RequestError) Error() string {
+	// a *url.Error already says which request failed, so say it only once
+	err := e.Err
+	var urlError *url.Error
+	if errors.As(err, &urlError) {
+		err = urlError.Err
+	}
+	return fmt.Sprintf("%s %s: %s", e.Method, e.URL, err)
+}
+
+// Unwrap returns the error that the request failed with
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// newRequestError returns the *RequestError for the given request failing
+// with err
+func newRequestError(req *http.Request, err error) *RequestError {
+	return &RequestError{Method: req.Method, URL: redactURL(req.URL), Err: err}
+}
+
 // handleResponse processes the response according to the HTTP status
 func handleResponse(res *http.Response, err error) ([]byte, *http.Response, error) {
 	if err != nil { // fall-through if there was an underlying err
 		return nil, res, err
 	}
+	defer res.Body.Close()
 
 	data, err := ioutil.ReadAll(res.Body)
 
@@ -25,10 +132,64 @@ func handleResponse(res *http.Response, err error) ([]byte, *http.Response, erro
 		return data, res, nil
 	}
 
-	// truncate the data to errorBodyLength bytes before returning it as part of the error
-	if len(data) >= errorBodyLength {
-		data = append(data[:errorBodyLength], []byte("...")...)
+	return nil, res, newAPIError(res, data)
+}
+
+// isRetryable reports whether a request that failed with the given response,
+// which is nil if there was no response, should be retried; a write only if
+// it is idempotent or RetryWrites is set
+func (s Server) isRetryable(idempotent bool, res *http.Response) bool {
+	if !idempotent && !s.RetryWrites {
+		return false
+	}
+	if res == nil { // the request failed without a response, e.g. a network error
+		return true
+	}
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryDelay returns how long to wait before retrying the request that failed
+// with the given response on the given attempt; the Retry-After header of the
+// response if it has one, otherwise an exponential backoff with jitter, either
+// way up to the maxRetryDelay.
+func (s Server) retryDelay(attempt int, res *http.Response) time.Duration {
+	if res != nil {
+		if retryAfter := res.Header.Get("Retry-After"); retryAfter != "" {
+			if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+				if seconds > int(maxRetryDelay/time.Second) {
+					return maxRetryDelay
+				}
+				return time.Duration(seconds) * time.Second
+			}
+			if at, err := http.ParseTime(retryAfter); err == nil {
+				if delay := time.Until(at); delay > maxRetryDelay {
+					return maxRetryDelay
+				} else if delay > 0 {
+					return delay
+				}
+				return 0
+			}
+		}
+	}
+
+	// double the backoff with each attempt, stopping at the maxRetryDelay
+	// rather than shifting it until it overflows
+	backoff := s.RetryBaseDelay
+	for i := 1; i < attempt && backoff < maxRetryDelay; i++ {
+		backoff *= 2
+	}
+	if backoff > maxRetryDelay {
+		backoff = maxRetryDelay
+	}
+	if backoff <= 0 {
+		return 0
 	}
 
-	return nil, res, fmt.Errorf("%s: %s", res.Status, string(data))
+	// wait for between half and all of the backoff so that concurrent
+	// callers don't retry in lockstep
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
 }
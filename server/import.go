@@ -0,0 +1,246 @@
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// importNameColumn is the CSV column, or JSON key, that holds the name of the
+// secret that ImportSecretsFromReader creates from a row, ignoring case
+const importNameColumn = "Name"
+
+// Format is the format of the rows that ImportSecretsFromReader reads, and
+// how their columns map to the slugs of the fields of the secrets
+type Format struct {
+	json    bool
+	columns map[string]string
+}
+
+// CSVFormat reads a header row and then a secret per row. The Name column
+// holds the secret's name, and the other columns the values of its fields,
+// by the slugs that columns maps their headers to. Columns that it does not
+// map are left out; if it is nil, the headers are the slugs.
+func CSVFormat(columns map[string]string) Format {
+	return Format{columns: columns}
+}
+
+// JSONFormat reads an array of objects, a secret per object, whose keys the
+// given keys map to slugs as CSVFormat maps columns. Numbers and booleans are
+// imported as they are written, and null as the empty string.
+func JSONFormat(keys map[string]string) Format {
+	return Format{json: true, columns: keys}
+}
+
+// ImportResult is the outcome of ImportSecretsFromReader. Rows are numbered
+// from 1, not counting the CSV header.
+type ImportResult struct {
+	Succeeded, Failed int
+	// IDs are the ids of the secrets that were created, by row
+	IDs map[int]int
+	// Errors are the rows that could not be imported, in order
+	Errors []ImportError
+}
+
+// ImportError is a row that ImportSecretsFromReader could not create a secret
+// from, with the Name of the secret, if it has one, and the Err saying why
+type ImportError struct {
+	Row  int
+	Name string
+	Err  error
+}
+
+func (e *ImportError) Error() string {
+	return fmt.Sprintf("row %d, secret named '%s': %s", e.Row, e.Name, e.Err)
+}
+
+// Unwrap returns the error that the row could not be imported with
+func (e *ImportError) Unwrap() error {
+	return e.Err
+}
+
+// importRow is a row that ImportSecretsFromReader read, with its values by
+// column
+type importRow struct {
+	number int
+	values map[string]string
+	err    error
+}
+
+// ImportSecretsFromReader creates a secret from the template with the given
+// templateID, in the folder with the given folderID, for each row that it
+// reads from r in the given format. It reads the rows as it creates them, so
+// a large import is not held in memory, making up to BulkConcurrency requests
+// at a time. The rows that a secret cannot be created from are counted as
+// Failed with their ImportError, and the import goes on. An error is returned,
+// along with the result so far, only if r cannot be read or parsed, or if the
+// template cannot be read, in which case nothing is created.
+func (s Server) ImportSecretsFromReader(r io.Reader, format Format, folderID, templateID int) (ImportResult, error) {
+	result := ImportResult{IDs: make(map[int]int), Errors: make([]ImportError, 0)}
+
+	// the workers create every secret from the same template, so read it once
+	importer := s
+	if importer.templates == nil {
+		importer.templates = newTemplateCache(time.Hour)
+	}
+	if _, err := importer.SecretTemplate(templateID); err != nil {
+		return result, err
+	}
+
+	concurrency := s.BulkConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultBulkConcurrency
+	}
+
+	rows := make(chan importRow)
+	var mutex sync.Mutex
+	var workers sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for row := range rows {
+				name, id, err := importer.importRow(row, format.columns, folderID, templateID)
+
+				mutex.Lock()
+				if err == nil {
+					result.Succeeded++
+					result.IDs[row.number] = id
+				} else {
+					result.Failed++
+					result.Errors = append(result.Errors, ImportError{Row: row.number, Name: name, Err: err})
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	var err error
+	if format.json {
+		err = readJSONRows(r, rows)
+	} else {
+		err = readCSVRows(r, rows)
+	}
+	close(rows)
+	workers.Wait()
+
+	sort.Slice(result.Errors, func(i, j int) bool { return result.Errors[i].Row < result.Errors[j].Row })
+	return result, err
+}
+
+// importRow creates the secret of the given row, whose columns are mapped to
+// slugs by columns, and returns its name and id
+func (s Server) importRow(row importRow, columns map[string]string, folderID, templateID int) (string, int, error) {
+	secret := Secret{FolderID: folderID, SecretTemplateID: templateID, SiteID: localSiteID}
+	names := make([]string, 0, len(row.values))
+	for column := range row.values {
+		names = append(names, column)
+	}
+	sort.Strings(names)
+
+	for _, column := range names {
+		value := row.values[column]
+		if strings.EqualFold(column, importNameColumn) {
+			secret.Name = value
+			continue
+		}
+		slug := column
+		if columns != nil {
+			var found bool
+			if slug, found = columns[column]; !found {
+				continue
+			}
+		}
+		secret.Fields = append(secret.Fields, SecretField{Slug: slug, ItemValue: value})
+	}
+	if row.err != nil {
+		return secret.Name, 0, row.err
+	}
+	if strings.TrimSpace(secret.Name) == "" {
+		return "", 0, fmt.Errorf("[ERROR] the row has no %s", importNameColumn)
+	}
+
+	created, err := s.CreateSecret(secret)
+	if err != nil {
+		return secret.Name, 0, err
+	}
+	return secret.Name, created.ID, nil
+}
+
+// readCSVRows sends the rows of the CSV that it reads from r, after its
+// header, to rows
+func readCSVRows(r io.Reader, rows chan<- importRow) error {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("[ERROR] reading the CSV header: %w", err)
+	}
+
+	for number := 1; ; number++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("[ERROR] reading row %d of the CSV: %w", number, err)
+		}
+
+		values := make(map[string]string, len(header))
+		for i, column := range header {
+			values[column] = record[i]
+		}
+		rows <- importRow{number: number, values: values}
+	}
+}
+
+// readJSONRows sends the objects of the JSON array that it reads from r to
+// rows, as they are decoded
+func readJSONRows(r io.Reader, rows chan<- importRow) error {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return fmt.Errorf("[ERROR] the JSON must be an array of secrets")
+	}
+
+	for number := 1; decoder.More(); number++ {
+		var object map[string]interface{}
+		if err := decoder.Decode(&object); err != nil {
+			var typeError *json.UnmarshalTypeError
+			if !errors.As(err, &typeError) {
+				return fmt.Errorf("[ERROR] reading secret %d of the JSON: %w", number, err)
+			}
+			rows <- importRow{number: number, err: fmt.Errorf("[ERROR] the secret is not a JSON object")}
+			continue
+		}
+
+		row := importRow{number: number, values: make(map[string]string, len(object))}
+		for key, value := range object {
+			switch v := value.(type) {
+			case nil:
+				row.values[key] = ""
+			case string:
+				row.values[key] = v
+			case json.Number:
+				row.values[key] = v.String()
+			case bool:
+				row.values[key] = fmt.Sprint(v)
+			default:
+				row.err = fmt.Errorf("[ERROR] the value of '%s' is not a string, a number or a boolean", key)
+			}
+		}
+		rows <- row
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return fmt.Errorf("[ERROR] reading the end of the JSON: %w", err)
+	}
+	return nil
+}
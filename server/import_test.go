@@ -0,0 +1,112 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// importFixture is a fixture that creates up to 4 secrets, failing to create
+// those named "bad", and records the secrets that it created by name
+type importFixture struct {
+	*fixture
+	mutex   sync.Mutex
+	created map[string]Secret
+}
+
+func newImportFixture(t *testing.T) *importFixture {
+	f := &importFixture{fixture: newFixture(t), created: make(map[string]Secret)}
+
+	f.respondWithFile("GET", "/api/v1/secret-templates/6001", "secret-template.json")
+	for id := 101; id <= 104; id++ {
+		f.respond("GET", fmt.Sprintf("/api/v1/secrets/%d", id), http.StatusOK, fmt.Sprintf(`{"ID": %d}`, id))
+	}
+	f.handle("POST", "/api/v1/secrets", func(w http.ResponseWriter, r *http.Request) {
+		var secret Secret
+		if err := json.NewDecoder(r.Body).Decode(&secret); err != nil {
+			t.Error("decoding the create request body:", err)
+		}
+		if secret.Name == "bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"message": "Invalid secret"}`)
+			return
+		}
+
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+		f.created[secret.Name] = secret
+		fmt.Fprintf(w, `{"ID": %d, "Name": %q}`, 100+len(f.created), secret.Name)
+	})
+	return f
+}
+
+// TestImportSecretsFromCSV validates that a secret is created from each row of
+// the CSV, with its columns mapped to slugs, and that the rows that fail are
+// reported without stopping the import.
+func TestImportSecretsFromCSV(t *testing.T) {
+	f := newImportFixture(t)
+	defer f.Close()
+
+	csv := "Name,User,Pass,Comment\n" +
+		"db-prod,app,Passw0rd.,ignored\n" +
+		"bad,app,Passw0rd.,\n" +
+		",app,Passw0rd.,\n" +
+		"db-test,\"test, user\",Passw0rd.,\n"
+
+	result, err := f.server().ImportSecretsFromReader(strings.NewReader(csv),
+		CSVFormat(map[string]string{"User": "username", "Pass": "password"}), 7, 6001)
+	if err != nil {
+		t.Fatal("calling server.ImportSecretsFromReader:", err)
+	}
+	if !validate("succeeded", 2, result.Succeeded, t) || !validate("failed", 2, result.Failed, t) {
+		return
+	}
+	if _, found := result.IDs[1]; !found || len(result.IDs) != 2 {
+		t.Errorf("expecting the ids of rows 1 and 4, but found %v", result.IDs)
+	}
+	validate("first failed row", 2, result.Errors[0].Row, t)
+	validate("first failed name", "bad", result.Errors[0].Name, t)
+	validate("second failed row", 3, result.Errors[1].Row, t)
+
+	secret := f.created["db-test"]
+	validate("folder id", 7, secret.FolderID, t)
+	validate("template id", 6001, secret.SecretTemplateID, t)
+	validate("fields", 2, len(secret.Fields), t)
+	if username, _ := secret.Field("username"); !validate("username", "test, user", username, t) {
+		return
+	}
+	validate("template requests", 1, f.count("GET", "/api/v1/secret-templates/6001"), t)
+}
+
+// TestImportSecretsFromJSON validates that a secret is created from each
+// object of the JSON, and that JSON that cannot be parsed stops the import.
+func TestImportSecretsFromJSON(t *testing.T) {
+	f := newImportFixture(t)
+	defer f.Close()
+
+	objects := `[{"name": "db-prod", "username": "app", "password": "Passw0rd.", "notes": 5432},
+		{"name": "nested", "username": {"first": "app"}}]`
+
+	tss := f.server()
+	result, err := tss.ImportSecretsFromReader(strings.NewReader(objects), JSONFormat(nil), 7, 6001)
+	if err != nil {
+		t.Fatal("calling server.ImportSecretsFromReader:", err)
+	}
+	if !validate("succeeded", 1, result.Succeeded, t) || !validate("failed", 1, result.Failed, t) {
+		return
+	}
+	validate("failed name", "nested", result.Errors[0].Name, t)
+	if notes, _ := f.created["db-prod"].Field("notes"); !validate("notes", "5432", notes, t) {
+		return
+	}
+
+	if _, err := tss.ImportSecretsFromReader(strings.NewReader(`[{"name": "db-test"`), JSONFormat(nil), 7, 6001); err == nil {
+		t.Error("expecting an error importing JSON that cannot be parsed")
+	}
+	if _, err := tss.ImportSecretsFromReader(strings.NewReader(`{"name": "db-test"}`), JSONFormat(nil), 7, 6001); err == nil {
+		t.Error("expecting an error importing JSON that is not an array")
+	}
+}
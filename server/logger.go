@@ -0,0 +1,119 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+)
+
+// redacted replaces the values that must not be logged
+const redacted = "********"
+
+// redactedKeys are the (lower case) names of the JSON properties whose values
+// are redacted from logged bodies, along with those of every property whose
+// name contains "password", e.g. NewPassword
+var redactedKeys = map[string]bool{
+	"itemvalue":          true,
+	"value":              true,
+	"password":           true,
+	"doublelockpassword": true,
+	"access_token":       true,
+	"refresh_token":      true,
+	"client_secret":      true,
+}
+
+// Logger receives the messages that the API logs as it makes requests
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// StdLogger is a Logger that logs to the standard logger, prefixing messages
+// with their level
+type StdLogger struct{}
+
+func (StdLogger) Debugf(format string, args ...interface{}) {
+	log.Printf("[DEBUG] "+format, args...)
+}
+
+func (StdLogger) Errorf(format string, args ...interface{}) {
+	log.Printf("[ERROR] "+format, args...)
+}
+
+// NopLogger is a Logger that discards the messages it receives
+type NopLogger struct{}
+
+func (NopLogger) Debugf(format string, args ...interface{}) {}
+
+func (NopLogger) Errorf(format string, args ...interface{}) {}
+
+// logger returns the configured Logger, or if there is none, a NopLogger
+func (s Server) logger() Logger {
+	if s.Logger != nil {
+		return s.Logger
+	}
+	return NopLogger{}
+}
+
+// redactBody returns the given JSON body with the values of the redactedKeys
+// replaced, so that it can be logged. A body that is not JSON is not logged.
+func redactBody(data []byte) string {
+	var body interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return fmt.Sprintf("of %d bytes", len(data))
+	}
+	redacted, _ := json.Marshal(redactValue(body))
+	return string(redacted)
+}
+
+// isRedactedKey returns true if the value of the JSON property or query
+// parameter with the given name must not be logged
+func isRedactedKey(key string) bool {
+	key = strings.ToLower(key)
+	return redactedKeys[key] || strings.Contains(key, "password")
+}
+
+// redactURL returns the given URL with its password, if it has one, and the
+// values of the query parameters named by the redactedKeys replaced
+func redactURL(u *url.URL) string {
+	copied := *u
+	if u.User != nil {
+		if _, found := u.User.Password(); found {
+			copied.User = url.UserPassword(u.User.Username(), redacted)
+		}
+	}
+
+	query := copied.Query()
+	for key := range query {
+		if isRedactedKey(key) {
+			query.Set(key, redacted)
+		}
+	}
+	if len(query) > 0 {
+		copied.RawQuery = query.Encode()
+	}
+	return copied.String()
+}
+
+// redactValue replaces the values of the redactedKeys within the given value
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, element := range v {
+			if isRedactedKey(key) {
+				if element != nil {
+					v[key] = redacted
+				}
+			} else {
+				v[key] = redactValue(element)
+			}
+		}
+	case []interface{}:
+		for i, element := range v {
+			v[i] = redactValue(element)
+		}
+	}
+	return value
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Option sets a field of the Configuration that NewWithOptions builds
+type Option func(config *Configuration)
+
+// NewWithOptions returns a Server with the Configuration that the given
+// options build, which New then validates and completes with its defaults.
+// Unlike New, it also returns an error if the credentials that the grant type
+// needs are missing, and there is no CredentialProvider, rather than leaving
+// the first request to fail.
+//
+//	tss, err := server.NewWithOptions(
+//		server.WithTenant("example"),
+//		server.WithCredentials(server.UserCredential{Username: "user", Password: "password"}),
+//		server.WithTimeout(10*time.Second),
+//	)
+func NewWithOptions(opts ...Option) (*Server, error) {
+	var config Configuration
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	switch {
+	case config.CredentialProvider != nil:
+	case config.GrantType == "" || config.GrantType == PasswordGrant:
+		if config.Credentials.Username == "" || config.Credentials.Password == "" {
+			return nil, fmt.Errorf("a username and a password must be set")
+		}
+	case config.GrantType == ClientCredentialsGrant:
+		if config.Credentials.ClientID == "" || config.Credentials.ClientSecret == "" {
+			return nil, fmt.Errorf("a client ID and a client secret must be set")
+		}
+	}
+
+	return New(config)
+}
+
+// WithTenant sets the Tenant of the Secret Server in the cloud
+func WithTenant(tenant string) Option {
+	return func(config *Configuration) {
+		config.Tenant = tenant
+	}
+}
+
+// WithTLD sets the TLD of the Tenant's URL, which defaults to com
+func WithTLD(tld string) Option {
+	return func(config *Configuration) {
+		config.TLD = tld
+	}
+}
+
+// WithServerURL sets the ServerURL of an on-premises Secret Server
+func WithServerURL(serverURL string) Option {
+	return func(config *Configuration) {
+		config.ServerURL = serverURL
+	}
+}
+
+// WithCredentials sets the Credentials to authenticate with the password grant
+func WithCredentials(credentials UserCredential) Option {
+	return func(config *Configuration) {
+		config.Credentials = credentials
+	}
+}
+
+// WithClientCredentials sets the client ID and secret of an application
+// account to authenticate with, and the ClientCredentialsGrant to do so
+func WithClientCredentials(clientID, clientSecret string) Option {
+	return func(config *Configuration) {
+		config.Credentials = UserCredential{ClientID: clientID, ClientSecret: clientSecret}
+		config.GrantType = ClientCredentialsGrant
+	}
+}
+
+// WithCredentialProvider sets the CredentialProvider that provides the
+// credentials to authenticate with
+func WithCredentialProvider(provider CredentialProvider) Option {
+	return func(config *Configuration) {
+		config.CredentialProvider = provider
+	}
+}
+
+// WithHTTPClient sets the HTTPClient that makes the requests
+func WithHTTPClient(client *http.Client) Option {
+	return func(config *Configuration) {
+		config.HTTPClient = client
+	}
+}
+
+// WithTimeout sets the Timeout of the requests
+func WithTimeout(timeout time.Duration) Option {
+	return func(config *Configuration) {
+		config.Timeout = timeout
+	}
+}
+
+// WithRetries sets how many times, at most, a request is attempted, and the
+// delay before the first retry, which later retries back off from
+func WithRetries(maxAttempts int, baseDelay time.Duration) Option {
+	return func(config *Configuration) {
+		config.RetryMaxAttempts = maxAttempts
+		config.RetryBaseDelay = baseDelay
+	}
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestNewWithOptions validates that the options set the Configuration and that
+// missing credentials are an error
+func TestNewWithOptions(t *testing.T) {
+	client := &http.Client{}
+	tss, err := NewWithOptions(
+		WithTenant("example"),
+		WithTLD("eu"),
+		WithCredentials(UserCredential{Username: "user", Password: "password"}),
+		WithHTTPClient(client),
+		WithTimeout(10*time.Second),
+		WithRetries(5, time.Second),
+	)
+	if err != nil {
+		t.Fatal("calling NewWithOptions:", err)
+	}
+	if tss.Tenant != "example" || tss.TLD != "eu" || tss.HTTPClient != client || tss.Timeout != 10*time.Second ||
+		tss.RetryMaxAttempts != 5 || tss.RetryBaseDelay != time.Second {
+		t.Errorf("expecting the options to be set, but found %+v", tss.Configuration)
+	}
+	validate("grant type", PasswordGrant, tss.GrantType, t)
+
+	tss, err = NewWithOptions(WithServerURL("https://example.test/SecretServer"), WithClientCredentials("id", "secret"))
+	if err != nil {
+		t.Fatal("calling NewWithOptions:", err)
+	}
+	validate("grant type", ClientCredentialsGrant, tss.GrantType, t)
+
+	for name, opts := range map[string][]Option{
+		"no credentials":   {WithTenant("example")},
+		"no password":      {WithTenant("example"), WithCredentials(UserCredential{Username: "user"})},
+		"no client secret": {WithTenant("example"), WithClientCredentials("id", "")},
+		"no tenant":        {WithCredentials(UserCredential{Username: "user", Password: "password"})},
+	} {
+		if _, err := NewWithOptions(opts...); err == nil {
+			t.Errorf("expecting an error with %s", name)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// AuthenticationError is returned by Ping and Authenticate when Secret Server
+// is reachable but it rejects the credentials, or the user they authenticate
+// is not allowed to use the API
+type AuthenticationError struct {
+	err error
+}
+
+func (e *AuthenticationError) Error() string {
+	return "[ERROR] authenticating with Secret Server: " + e.err.Error()
+}
+
+// Unwrap returns the error that reported the failure, which is an *APIError
+func (e *AuthenticationError) Unwrap() error {
+	return e.err
+}
+
+// ConnectionError is returned by Ping and Authenticate when Secret Server
+// cannot be reached, e.g. because the ServerURL is wrong or the network is down
+type ConnectionError struct {
+	err error
+}
+
+func (e *ConnectionError) Error() string {
+	return "[ERROR] connecting to Secret Server: " + e.err.Error()
+}
+
+// Unwrap returns the error that reported the failure
+func (e *ConnectionError) Unwrap() error {
+	return e.err
+}
+
+// Authenticate gets an access token with the credentials, and caches it for
+// the requests that follow, e.g. to check the credentials at startup rather
+// than on the first read of a secret. It returns nil if the token is granted,
+// or is already cached, an *AuthenticationError if the credentials are
+// rejected and a *ConnectionError if Secret Server cannot be reached. Unlike
+// Ping, it does not check that the user may use the API.
+func (s Server) Authenticate() error {
+	return s.AuthenticateWithContext(context.Background())
+}
+
+// AuthenticateWithContext is Authenticate with a ctx that governs the request
+func (s Server) AuthenticateWithContext(ctx context.Context) error {
+	if _, err := s.getAccessToken(ctx); err != nil {
+		return pingError(ctx, err, true)
+	}
+	return nil
+}
+
+// Ping checks that Secret Server is reachable and that the credentials are
+// valid by authenticating and getting the current user. It returns nil if
+// both succeed, an *AuthenticationError if the credentials are rejected and a
+// *ConnectionError if Secret Server cannot be reached.
+func (s Server) Ping() error {
+	return s.PingWithContext(context.Background())
+}
+
+// PingWithContext is Ping with a ctx that governs the requests
+func (s Server) PingWithContext(ctx context.Context) error {
+	// get the token separately so that a rejected grant, which the token
+	// endpoint reports with a 400, can be told apart from other failures
+	if err := s.AuthenticateWithContext(ctx); err != nil {
+		return err
+	}
+	if _, err := s.accessResourceWithContext(ctx, "GET", userResource, currentUserPath, nil); err != nil {
+		return pingError(ctx, err, false)
+	}
+	return nil
+}
+
+// pingError classifies the given error that a Ping failed with
+func pingError(ctx context.Context, err error, granting bool) error {
+	if ctx.Err() != nil {
+		return err
+	}
+
+	var apiError *APIError
+	if errors.As(err, &apiError) {
+		switch apiError.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return &AuthenticationError{err}
+		case http.StatusBadRequest:
+			if granting {
+				return &AuthenticationError{err}
+			}
+		}
+		return err
+	}
+
+	var urlError *url.Error
+	if errors.As(err, &urlError) {
+		return &ConnectionError{err}
+	}
+	return err
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestPing validates that Ping succeeds when the current user can be fetched
+// and that it tells authentication failures apart from connection failures.
+func TestPing(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.respond("GET", "/api/v1/users/current", http.StatusOK, `{"id": 7, "userName": "fixture-user"}`)
+
+	tss := f.server()
+	if err := tss.Ping(); err != nil {
+		t.Fatal("calling server.Ping:", err)
+	}
+
+	f.respond("GET", "/api/v1/users/current", http.StatusForbidden, `{"message": "Access denied"}`)
+	if _, ok := tss.Ping().(*AuthenticationError); !ok {
+		t.Error("expecting an *AuthenticationError when the user is forbidden")
+	}
+
+	f.respond("POST", "/oauth2/token", http.StatusBadRequest, `{"error": "invalid_grant"}`)
+	if _, ok := f.server().Ping().(*AuthenticationError); !ok {
+		t.Error("expecting an *AuthenticationError when the grant is rejected")
+	}
+
+	f.Close()
+	if _, ok := f.server().Ping().(*ConnectionError); !ok {
+		t.Error("expecting a *ConnectionError when the server is unreachable")
+	}
+}
+
+// TestAuthenticate validates that Authenticate caches the token for the
+// requests that follow, and reports a rejected grant as an
+// *AuthenticationError.
+func TestAuthenticate(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.respond("GET", "/api/v1/secrets/42", http.StatusOK, `{"ID": 42}`)
+
+	tss := f.server()
+	if err := tss.Authenticate(); err != nil {
+		t.Fatal("calling server.Authenticate:", err)
+	}
+	validate("token requests", 1, f.count("POST", "/oauth2/token"), t)
+	if _, err := tss.Secret(42); err != nil {
+		t.Fatal("calling server.Secret:", err)
+	}
+	validate("token requests after reading a secret", 1, f.count("POST", "/oauth2/token"), t)
+
+	f.respond("POST", "/oauth2/token", http.StatusBadRequest, `{"error": "invalid_grant"}`)
+	if _, ok := f.server().Authenticate().(*AuthenticationError); !ok {
+		t.Error("expecting an *AuthenticationError when the grant is rejected")
+	}
+}
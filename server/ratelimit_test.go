@@ -0,0 +1,47 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestRateLimit validates that requests over the burst wait for the rate, and
+// that a waiting request ends with its ctx.
+func TestRateLimit(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.respond("GET", "/api/v1/secrets/42", http.StatusOK, `{"ID": 42}`)
+
+	tss, err := New(Configuration{
+		Credentials:    UserCredential{Username: "fixture-user", Password: "fixture-password"},
+		ServerURL:      f.URL,
+		RateLimit:      20,
+		RateLimitBurst: 2,
+	})
+	if err != nil {
+		t.Fatal("configuring the Server:", err)
+	}
+
+	started := time.Now()
+	for i := 0; i < 5; i++ {
+		if _, err := tss.Secret(42); err != nil {
+			t.Fatal("calling server.Secret:", err)
+		}
+	}
+	// the burst lets 2 requests through at once, and each of the other 3 waits
+	// for 50 milliseconds
+	if elapsed := time.Since(started); elapsed < 140*time.Millisecond || elapsed > time.Second {
+		t.Errorf("expecting 5 requests to take about 150ms, but they took %s", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	tss.limiter = newRateLimiter(0.1, 1)
+	tss.limiter.reserve()
+	if _, err := tss.SecretWithContext(ctx, 42); err != context.DeadlineExceeded {
+		t.Errorf("expecting the request to end with its ctx, but found '%v' instead", err)
+	}
+}
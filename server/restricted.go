@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// RestrictedArgs are what is asked for when viewing a restricted secret, that
+// is, one that requires a comment, and perhaps a ticket number, to be viewed,
+// or one that is protected by a double lock, whose password is needed to
+// decrypt its fields
+type RestrictedArgs struct {
+	Comment, TicketNumber string
+	TicketSystemID        int    `json:",omitempty"`
+	DoubleLockPassword    string `json:",omitempty"`
+}
+
+// CommentRequiredError is returned when the secret with the given ID requires
+// a comment to be viewed and none was given, e.g. because it was read with
+// Secret rather than with SecretWithComment
+type CommentRequiredError struct {
+	ID int
+}
+
+func (e *CommentRequiredError) Error() string {
+	return fmt.Sprintf("the secret with id '%d' requires a comment to be viewed", e.ID)
+}
+
+// MissingRestrictedArgsError is returned, without a request being made, when
+// the args that viewing the secret with the given ID requires are not given;
+// Missing names each of them, e.g. "ticket number"
+type MissingRestrictedArgsError struct {
+	ID      int
+	Missing []string
+}
+
+func (e *MissingRestrictedArgsError) Error() string {
+	return fmt.Sprintf("the secret with id '%d' requires a %s to be viewed", e.ID, strings.Join(e.Missing, " and a "))
+}
+
+// DoubleLockPasswordRequiredError is returned when the secret with the given ID
+// is protected by a double lock and no double lock password was given
+type DoubleLockPasswordRequiredError struct {
+	ID int
+}
+
+func (e *DoubleLockPasswordRequiredError) Error() string {
+	return fmt.Sprintf("the secret with id '%d' is protected by a double lock and requires its password to be viewed", e.ID)
+}
+
+// SecretWithComment gets the secret with the given id, giving the comment as
+// the reason for viewing it, as restricted secrets require
+func (s Server) SecretWithComment(id int, comment string) (*Secret, error) {
+	return s.RestrictedSecret(id, RestrictedArgs{Comment: comment})
+}
+
+// SecretWithTicket gets the secret with the given id, giving the comment and
+// the number of a ticket in the ticket system with the given id as the reasons
+// for viewing it, as secrets behind a ticketing system integration require.
+// The ticket system id may be 0 for the server's default ticket system. A
+// *MissingRestrictedArgsError is returned if the comment or the ticket number
+// is empty.
+func (s Server) SecretWithTicket(id int, comment, ticketNumber string, ticketSystemID int) (*Secret, error) {
+	missing := make([]string, 0)
+	if strings.TrimSpace(comment) == "" {
+		missing = append(missing, "comment")
+	}
+	if strings.TrimSpace(ticketNumber) == "" {
+		missing = append(missing, "ticket number")
+	}
+	if len(missing) > 0 {
+		return nil, &MissingRestrictedArgsError{ID: id, Missing: missing}
+	}
+
+	return s.RestrictedSecret(id, RestrictedArgs{Comment: comment, TicketNumber: ticketNumber, TicketSystemID: ticketSystemID})
+}
+
+// SecretWithDoubleLockPassword gets the secret with the given id, which is
+// protected by a double lock, decrypting it with the given password
+func (s Server) SecretWithDoubleLockPassword(id int, password string) (*Secret, error) {
+	return s.RestrictedSecret(id, RestrictedArgs{DoubleLockPassword: password})
+}
+
+// RestrictedSecret gets the secret with the given id, giving the comment and
+// ticket of the given args as the reasons for viewing it, and their double lock
+// password to decrypt it. Its file attachments are downloaded with the same
+// args, unless SkipFileDownloads is set. A *CommentRequiredError or a
+// *DoubleLockPasswordRequiredError is returned if the secret requires a comment
+// or a double lock password and the args have none.
+func (s Server) RestrictedSecret(id int, args RestrictedArgs) (*Secret, error) {
+	ctx := context.Background()
+	secret := new(Secret)
+	path := fmt.Sprintf("%d/restricted", id)
+
+	if data, err := s.accessResourceWithContext(ctx, "POST", resource, path, args); err == nil {
+		if err = s.unmarshal(data, secret); err != nil {
+			s.logger().Errorf("error parsing response from /%s/%s: %s", resource, path, redactBody(data))
+			return nil, err
+		}
+	} else {
+		return nil, s.restrictionError(ctx, id, args, err)
+	}
+
+	if s.SkipFileDownloads {
+		return secret, nil
+	}
+
+	for index, element := range secret.Fields {
+		if element.IsFile && element.FileAttachmentID != 0 && element.Filename != "" {
+			path := fmt.Sprintf("%d/restricted/fields/%s", id, element.Slug)
+
+			if data, err := s.accessResourceWithClient(ctx, s.fileDownloadClient(), "POST", resource, path, args); err == nil {
+				secret.Fields[index].ItemValue = string(data)
+			} else {
+				return nil, err
+			}
+		}
+	}
+
+	return secret, nil
+}
+
+// restrictionError returns the error to report when reading the secret with
+// the given id with the given args failed with err. The server does not say
+// why it refused to show a restricted secret, so when it responded with a 400
+// or a 403, the secret's summary is checked for the restriction that the args
+// do not satisfy. Otherwise, err is returned as it is.
+func (s Server) restrictionError(ctx context.Context, id int, args RestrictedArgs, err error) error {
+	var apiError *APIError
+	if ctx.Err() != nil || !errors.As(err, &apiError) ||
+		apiError.StatusCode != http.StatusBadRequest && apiError.StatusCode != http.StatusForbidden {
+		return err
+	}
+
+	if summary, sErr := s.secretSummary(ctx, id); sErr == nil {
+		switch {
+		case summary.RequiresComment && strings.TrimSpace(args.Comment) == "":
+			return &CommentRequiredError{ID: id}
+		case summary.DoubleLockEnabled && args.DoubleLockPassword == "":
+			return &DoubleLockPasswordRequiredError{ID: id}
+		}
+	}
+	return err
+}
+
+// doubleLockError returns a *DoubleLockPasswordRequiredError if the given
+// secret, which has the given id and was read without a double lock password,
+// is protected by a double lock, since the server then responds with
+// placeholders in place of the values of its fields rather than refusing
+func doubleLockError(id int, secret *Secret) error {
+	if secret.IsDoubleLock {
+		return &DoubleLockPasswordRequiredError{ID: id}
+	}
+	return nil
+}
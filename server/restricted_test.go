@@ -0,0 +1,127 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// TestSecretWithComment validates that the comment is sent to view a restricted
+// secret, and that reading it without one is reported as such.
+func TestSecretWithComment(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	var args RestrictedArgs
+	f.respond("GET", "/api/v1/secrets/42", http.StatusBadRequest, `{"message": "Access Denied"}`)
+	f.respond("GET", "/api/v1/secrets/42/summary", http.StatusOK, `{"id": 42, "requiresComment": true}`)
+	f.handle("POST", "/api/v1/secrets/42/restricted", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+			t.Error("decoding the restricted request body:", err)
+		}
+		fmt.Fprint(w, `{"ID": 42, "Name": "Test Secret"}`)
+	})
+
+	tss := f.server()
+	_, err := tss.Secret(42)
+	var commentRequired *CommentRequiredError
+	if !errors.As(err, &commentRequired) || commentRequired.ID != 42 {
+		t.Errorf("expecting a *CommentRequiredError, but found '%v' instead", err)
+	}
+
+	secret, err := tss.SecretWithComment(42, "rotating the database credentials")
+	if err != nil {
+		t.Fatal("calling server.SecretWithComment:", err)
+	}
+	validate("secret name", "Test Secret", secret.Name, t)
+	validate("comment", "rotating the database credentials", args.Comment, t)
+}
+
+// TestSecretWithTicket validates that the comment and ticket are sent to view a
+// restricted secret, and that the missing ones are reported without a request.
+func TestSecretWithTicket(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	var args RestrictedArgs
+	f.handle("POST", "/api/v1/secrets/42/restricted", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+			t.Error("decoding the restricted request body:", err)
+		}
+		fmt.Fprint(w, `{"ID": 42, "Name": "Test Secret"}`)
+	})
+
+	tss := f.server()
+	_, err := tss.SecretWithTicket(42, " ", "", 3)
+	var missing *MissingRestrictedArgsError
+	if !errors.As(err, &missing) || len(missing.Missing) != 2 {
+		t.Errorf("expecting a *MissingRestrictedArgsError for the comment and ticket number, but found '%v' instead", err)
+	}
+	if count := f.count("POST", "/api/v1/secrets/42/restricted"); count != 0 {
+		t.Errorf("expecting no request without the comment and ticket number, but found %d", count)
+	}
+
+	if _, err := tss.SecretWithTicket(42, "deploying", "CHG-1234", 3); err != nil {
+		t.Fatal("calling server.SecretWithTicket:", err)
+	}
+	validate("comment", "deploying", args.Comment, t)
+	validate("ticket number", "CHG-1234", args.TicketNumber, t)
+	validate("ticket system id", 3, args.TicketSystemID, t)
+}
+
+// TestSecretWithDoubleLockPassword validates that the double lock password is
+// sent, and that reading a double locked secret without it is reported as such.
+func TestSecretWithDoubleLockPassword(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.respond("GET", "/api/v1/secrets/42", http.StatusForbidden, `{"message": "Access Denied"}`)
+	f.respond("GET", "/api/v1/secrets/42/summary", http.StatusOK, `{"id": 42, "doubleLockEnabled": true}`)
+	f.handle("POST", "/api/v1/secrets/42/restricted", func(w http.ResponseWriter, r *http.Request) {
+		var args RestrictedArgs
+		if err := json.NewDecoder(r.Body).Decode(&args); err != nil || args.DoubleLockPassword != "d0uble" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message": "Access Denied"}`)
+			return
+		}
+		fmt.Fprint(w, `{"ID": 42, "Items": [{"Slug": "password", "ItemValue": "Passw0rd."}]}`)
+	})
+
+	tss := f.server()
+	_, err := tss.Secret(42)
+	var passwordRequired *DoubleLockPasswordRequiredError
+	if !errors.As(err, &passwordRequired) {
+		t.Errorf("expecting a *DoubleLockPasswordRequiredError, but found '%v' instead", err)
+	}
+
+	secret, err := tss.SecretWithDoubleLockPassword(42, "d0uble")
+	if err != nil {
+		t.Fatal("calling server.SecretWithDoubleLockPassword:", err)
+	}
+	if password, _ := secret.Field("password"); !validate("password", "Passw0rd.", password, t) {
+		return
+	}
+}
+
+// TestSecretWithDoubleLockPlaceholders validates that a double locked secret
+// that the server returns with placeholders in place of its values, rather
+// than refusing, is reported as requiring its password.
+func TestSecretWithDoubleLockPlaceholders(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.respond("GET", "/api/v1/secrets/42", http.StatusOK, `{"ID": 42, "IsDoubleLock": true,
+		"Items": [{"Slug": "password", "ItemValue": "*** Not Valid For Display ***"}]}`)
+
+	tss := f.server()
+	_, err := tss.Secret(42)
+	var passwordRequired *DoubleLockPasswordRequiredError
+	if !errors.As(err, &passwordRequired) || passwordRequired.ID != 42 {
+		t.Errorf("expecting a *DoubleLockPasswordRequiredError for secret 42, but found '%v' instead", err)
+	}
+	if _, _, err = tss.SecretRaw(42); !errors.As(err, &passwordRequired) {
+		t.Errorf("expecting a *DoubleLockPasswordRequiredError from server.SecretRaw, but found '%v' instead", err)
+	}
+}
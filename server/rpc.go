@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// ChangePassword changes the password of the secret with the given id on the
+// system that it is for, via remote password changing, and stores it. If
+// newPassword is empty, the server generates one. This differs from
+// UpdateSecretField, which stores a value without changing it on the system.
+func (s Server) ChangePassword(id int, newPassword string) error {
+	input := struct {
+		NewPassword string `json:",omitempty"`
+	}{NewPassword: newPassword}
+
+	_, err := s.accessResource("POST", resource, fmt.Sprintf("%d/change-password", id), input)
+	return err
+}
+
+// SecretDependency is something that uses a secret, such as a service, which
+// must be updated when the secret's password changes
+type SecretDependency struct {
+	ID, SecretID                          int
+	TypeName, DependencyName, MachineName string
+	Status                                string
+	Active                                bool
+}
+
+// SecretDependencies returns the dependencies of the secret with the given id
+func (s Server) SecretDependencies(id int) ([]SecretDependency, error) {
+	dependencies := make([]SecretDependency, 0)
+
+	err := s.eachPage(context.Background(), resource, fmt.Sprintf("%d/dependencies", id), nil, func(record json.RawMessage) error {
+		var dependency SecretDependency
+		if err := json.Unmarshal(record, &dependency); err != nil {
+			return err
+		}
+		dependencies = append(dependencies, dependency)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dependencies, nil
+}
+
+// RunSecretDependencies updates the dependencies of the secret with the given
+// id with its current password, e.g. after ChangePassword
+func (s Server) RunSecretDependencies(id int) error {
+	_, err := s.accessResource("POST", resource, fmt.Sprintf("%d/dependencies/run", id), nil)
+	return err
+}
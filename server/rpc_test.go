@@ -0,0 +1,51 @@
+package server
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+// TestSecretDependencies validates that every page of a secret's dependencies
+// is read, following HasNext.
+func TestSecretDependencies(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.handle("GET", "/api/v1/secrets/42/dependencies", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("paging.skip") == "0" {
+			w.Write([]byte(`{"records": [{"id": 1, "secretId": 42, "typeName": "Windows Service", "dependencyName": "app",
+				"machineName": "web-1", "active": true}], "hasNext": true}`))
+			return
+		}
+		w.Write([]byte(`{"records": [{"id": 2, "secretId": 42, "typeName": "IIS Application Pool", "dependencyName": "pool",
+			"machineName": "web-2", "active": true}], "hasNext": false}`))
+	})
+
+	dependencies, err := f.server().SecretDependencies(42)
+	if err != nil {
+		t.Fatal("calling server.SecretDependencies:", err)
+	}
+	if !validate("dependencies", 2, len(dependencies), t) || !validate("pages", 2, f.count("GET", "/api/v1/secrets/42/dependencies"), t) {
+		return
+	}
+	validate("second dependency machine name", "web-2", dependencies[1].MachineName, t)
+}
+
+// TestRunSecretDependencies validates that the dependencies are run with a POST
+// to the secret's dependencies, without a body.
+func TestRunSecretDependencies(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.handle("POST", "/api/v1/secrets/42/dependencies/run", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		validate("body", "", string(body), t)
+		w.Write([]byte(`{}`))
+	})
+
+	if err := f.server().RunSecretDependencies(42); err != nil {
+		t.Fatal("calling server.RunSecretDependencies:", err)
+	}
+	validate("run requests", 1, f.count("POST", "/api/v1/secrets/42/dependencies/run"), t)
+}
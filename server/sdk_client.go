@@ -0,0 +1,37 @@
+package server
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// SDKClientCredential returns the credential of an SDK client account, that
+// is, one that was onboarded with a client onboarding rule and its key rather
+// than created for a person, with the given client ID and the client secret
+// that is read from the file with the given path. Leading and trailing white
+// space in the file is ignored. Use it with the ClientCredentialsGrant, e.g.
+//
+//	credential, err := server.SDKClientCredential("sdk-client-...", "/run/secrets/tss-client-secret")
+//	...
+//	tss, err := server.New(server.Configuration{
+//		Credentials: credential,
+//		GrantType:   server.ClientCredentialsGrant,
+//		ServerURL:   "https://example.com/SecretServer",
+//	})
+func SDKClientCredential(clientID, secretPath string) (UserCredential, error) {
+	if strings.TrimSpace(clientID) == "" {
+		return UserCredential{}, fmt.Errorf("[ERROR] the SDK client ID must not be empty")
+	}
+
+	data, err := ioutil.ReadFile(secretPath)
+	if err != nil {
+		return UserCredential{}, fmt.Errorf("[ERROR] reading the SDK client secret: %w", err)
+	}
+	clientSecret := strings.TrimSpace(string(data))
+	if clientSecret == "" {
+		return UserCredential{}, fmt.Errorf("[ERROR] the SDK client secret file '%s' is empty", secretPath)
+	}
+
+	return UserCredential{ClientID: clientID, ClientSecret: clientSecret}, nil
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+)
+
+// TestSDKClientCredential validates that the client secret is read from its
+// file and exchanged for an access token with the client credentials grant.
+func TestSDKClientCredential(t *testing.T) {
+	file, err := ioutil.TempFile("", "tss-client-secret")
+	if err != nil {
+		t.Fatal("creating the client secret file:", err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("client-secret\n")
+	file.Close()
+
+	credential, err := SDKClientCredential("sdk-client-1", file.Name())
+	if err != nil {
+		t.Fatal("calling SDKClientCredential:", err)
+	}
+
+	f := newFixture(t)
+	defer f.Close()
+
+	f.handle("POST", "/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		validate("grant type", ClientCredentialsGrant, r.FormValue("grant_type"), t)
+		validate("client id", "sdk-client-1", r.FormValue("client_id"), t)
+		validate("client secret", "client-secret", r.FormValue("client_secret"), t)
+		w.Write([]byte(`{"access_token": "fixture-token", "token_type": "bearer", "expires_in": 1200}`))
+	})
+
+	tss, err := New(Configuration{Credentials: credential, GrantType: ClientCredentialsGrant, ServerURL: f.URL})
+	if err != nil {
+		t.Fatal("configuring the Server:", err)
+	}
+	if err := tss.Authenticate(); err != nil {
+		t.Fatal("calling server.Authenticate:", err)
+	}
+
+	if _, err := SDKClientCredential("sdk-client-1", file.Name()+".missing"); err == nil {
+		t.Error("expecting an error when the client secret file is missing")
+	}
+	if _, err := SDKClientCredential("", file.Name()); err == nil {
+		t.Error("expecting an error when the client ID is empty")
+	}
+}
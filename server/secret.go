@@ -1,35 +1,64 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"io"
+	"net/url"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // resource is the HTTP URL path component for the secrets resource
 const resource = "secrets"
 
-// Secret represents a secret from Delinea Secret Server
+// searchPageSize is the number of records that are requested per page when
+// searching for secrets
+const searchPageSize = 100
+
+// Secret represents a secret from Delinea Secret Server. SecretTemplateName,
+// IsDoubleLock, IsRestricted and RequiresApprovalForAccess are set by the
+// server when the secret is read, and are ignored on writes.
 type Secret struct {
 	Name                                                                       string
+	SecretTemplateName                                                         string `json:",omitempty"`
 	FolderID, ID, SiteID, SecretTemplateID                                     int
 	SecretPolicyID, PasswordTypeWebScriptID                                    int `json:",omitempty"`
 	LauncherConnectAsSecretID, CheckOutIntervalMinutes                         int
-	Active, CheckedOut, CheckOutEnabled                                        bool
+	Active, CheckOutEnabled                                                    bool
+	CheckedOut                                                                 bool `json:",omitempty"`
 	AutoChangeEnabled, CheckOutChangePasswordEnabled, DelayIndexing            bool
 	EnableInheritPermissions, EnableInheritSecretPolicy, ProxyEnabled          bool
 	RequiresComment, SessionRecordingEnabled, WebLauncherRequiresIncognitoMode bool
+	IsDoubleLock, IsRestricted, RequiresApprovalForAccess                      bool          `json:",omitempty"`
 	Fields                                                                     []SecretField `json:"Items"`
 	SshKeyArgs                                                                 *SshKeyArgs   `json:",omitempty"`
+	// LastModified, Created and LastModifiedBy are set by the server, and are
+	// nil or empty when it does not return them, e.g. for a new secret
+	LastModified   *Time  `json:"LastModifiedDate,omitempty"`
+	Created        *Time  `json:"CreateDate,omitempty"`
+	LastModifiedBy string `json:"LastModifiedByDisplayName,omitempty"`
 }
 
-// SecretField is an item (field) in the secret
+// SecretField is an item (field) in the secret. FileAttachmentID,
+// FieldDescription and Filename are left out of writes when they are empty,
+// since the server sets the first two and only file fields have the last.
 type SecretField struct {
-	ItemID, FieldID, FileAttachmentID     int
-	FieldName, Slug                       string
-	FieldDescription, Filename, ItemValue string
-	IsFile, IsNotes, IsPassword           bool
+	ItemID, FieldID             int
+	FileAttachmentID            int `json:",omitempty"`
+	FieldName, Slug             string
+	FieldDescription, Filename  string `json:",omitempty"`
+	ItemValue                   string
+	IsFile, IsNotes, IsPassword bool
+	// IsRequired, IsUrl, IsList, ListType and PasswordRequirementID are the
+	// metadata of the field's template field, which are only set when the
+	// secret is read with the WithTemplateMetadata option, and never sent
+	IsRequired, IsUrl, IsList bool   `json:"-"`
+	ListType                  string `json:"-"`
+	PasswordRequirementID     int    `json:"-"`
 }
 
 type SearchResult struct {
@@ -37,6 +66,54 @@ type SearchResult struct {
 	Records    []Secret
 }
 
+// SecretSummary is the subset of a secret that is returned by searches and
+// listings, such as SearchSecrets and FolderSecrets, without any of its
+// fields. Get the whole secret with Secret when it is needed.
+//
+// Its flags tell what viewing the secret takes, before trying to: a comment
+// when RequiresComment is set, which SecretWithComment gives, the double lock
+// password when DoubleLockEnabled is set, an approved access request when
+// RequiresApproval is set, and checking it out when CheckOutEnabled is set.
+type SecretSummary struct {
+	Name, SecretTemplateName               string
+	ID, FolderID, SiteID, SecretTemplateID int
+	Active, CheckedOut, CheckOutEnabled    bool
+	RequiresComment, RequiresApproval      bool
+	DoubleLockEnabled, IsRestricted        bool
+	LastAccessed                           Time
+}
+
+// secretSummaryPage is a page of the records found by a search
+type secretSummaryPage struct {
+	Records    []SecretSummary
+	Skip, Take int
+	Total      int
+	HasNext    bool
+}
+
+// SecretVersion summarizes a past version of a secret
+type SecretVersion struct {
+	Version, UserID int
+	UserDisplayName string
+	Date            Time
+}
+
+// SecretAuditEntry is an entry in the audit log of a secret
+type SecretAuditEntry struct {
+	SecretAuditID, SecretID                     int
+	Action, Notes, ByUserDisplayName, IpAddress string
+	MachineName, DatabaseName                   string
+	DateRecorded                                Time
+}
+
+// FieldChange describes a past change to the value of a field of a secret,
+// without the value itself
+type FieldChange struct {
+	SecretItemHistoryID, UserID int
+	UserDisplayName             string
+	Date                        Time
+}
+
 // SshKeyArgs control whether to generate an SSH key pair and a private key
 // passphrase when the secret template supports such generation.
 //
@@ -46,42 +123,315 @@ type SshKeyArgs struct {
 	GeneratePassphrase, GenerateSshKeys bool
 }
 
-// Secret gets the secret with id from the Secret Server of the given tenant
-func (s Server) Secret(id int) (*Secret, error) {
-	secret := new(Secret)
+// FieldNotFoundError is returned when a secret has no field with the given
+// name or slug
+type FieldNotFoundError struct {
+	SecretID  int
+	FieldName string
+}
+
+func (e *FieldNotFoundError) Error() string {
+	return fmt.Sprintf("no field with name or slug '%s' on the secret with id '%d'", e.FieldName, e.SecretID)
+}
+
+// InactiveSecretError is returned when deleting a secret that is already
+// inactive, i.e. that has already been deleted
+type InactiveSecretError struct {
+	ID int
+}
+
+func (e *InactiveSecretError) Error() string {
+	return fmt.Sprintf("the secret with id '%d' is already inactive", e.ID)
+}
 
-	if data, err := s.accessResource("GET", resource, strconv.Itoa(id), nil); err == nil {
-		if err = json.Unmarshal(data, secret); err != nil {
-			log.Printf("[ERROR] error parsing response from /%s/%d: %q", resource, id, data)
+// Secret gets the secret with id from the Secret Server of the given tenant.
+// The given options set query parameters of the request for the secret, e.g.
+// WithInactive to get it even if it has been deleted.
+func (s Server) Secret(id int, opts ...SecretOption) (*Secret, error) {
+	return s.SecretWithContext(context.Background(), id, opts...)
+}
+
+// SecretWithContext is Secret with a ctx that governs the requests made,
+// including the downloads of the secret's file attachments. A
+// *DoubleLockPasswordRequiredError is returned if the secret is protected by a
+// double lock; read it with SecretWithDoubleLockPassword instead.
+func (s Server) SecretWithContext(ctx context.Context, id int, opts ...SecretOption) (*Secret, error) {
+	options := newSecretOptions(opts)
+	cacheable := s.secrets != nil && options.cacheable()
+
+	var secret *Secret
+	if cacheable && !options.bypassCache {
+		secret, _ = s.secrets.get(id)
+	}
+	if secret == nil {
+		var err error
+		if secret, _, err = s.readSecretRaw(ctx, id, options.query); err != nil {
 			return nil, err
 		}
-	} else {
-		return nil, err
+		if err := doubleLockError(id, secret); err != nil {
+			return nil, err
+		}
+		if err := s.downloadFiles(ctx, id, secret); err != nil {
+			return nil, err
+		}
+		if cacheable {
+			s.secrets.put(id, secret)
+		}
+	}
+
+	if options.templateMetadata {
+		template, err := s.SecretTemplateWithContext(ctx, secret.SecretTemplateID)
+		if err != nil {
+			return nil, err
+		}
+		secret.addTemplateMetadata(template)
+	}
+	return secret, nil
+}
+
+// SecretIncludingInactive gets the secret with id even if it is inactive, i.e.
+// has been deleted, in which case its Active flag is false, e.g. to inspect it
+// before deciding whether to bring it back with RestoreSecret. It is Secret
+// with the WithInactive option.
+func (s Server) SecretIncludingInactive(id int) (*Secret, error) {
+	return s.Secret(id, WithInactive())
+}
+
+// SecretRaw is Secret that also returns the body of the server's response, the
+// secret's JSON as it was received, e.g. to archive it. The file attachments
+// that Secret downloads are not part of the body.
+func (s Server) SecretRaw(id int) (*Secret, []byte, error) {
+	ctx := context.Background()
+	secret, data, err := s.readSecretRaw(ctx, id, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := doubleLockError(id, secret); err != nil {
+		return nil, nil, err
+	}
+	if err := s.downloadFiles(ctx, id, secret); err != nil {
+		return nil, nil, err
+	}
+	return secret, data, nil
+}
+
+// downloadFiles downloads the file attachments of the given secret, which has
+// the given id, and substitutes them for the (dummy) ItemValue of its file
+// fields, so as to make the process transparent to the caller, unless
+// SkipFileDownloads is set
+func (s Server) downloadFiles(ctx context.Context, id int, secret *Secret) error {
+	if s.SkipFileDownloads {
+		return nil
 	}
 
-	// automatically download file attachments and substitute them for the
-	// (dummy) ItemValue, so as to make the process transparent to the caller
 	for index, element := range secret.Fields {
 		if element.IsFile && element.FileAttachmentID != 0 && element.Filename != "" {
 			path := fmt.Sprintf("%d/fields/%s", id, element.Slug)
 
-			if data, err := s.accessResource("GET", resource, path, nil); err == nil {
+			if data, err := s.accessResourceWithClient(ctx, s.fileDownloadClient(), "GET", resource, path, nil); err == nil {
 				secret.Fields[index].ItemValue = string(data)
 			} else {
-				return nil, err
+				return err
 			}
 		}
 	}
 
+	return nil
+}
+
+// SecretMetadataOnly gets the secret with id without downloading its file
+// attachments, as Secret does when SkipFileDownloads is set, so the ItemValue
+// of its file fields is what the server returns in their place and their
+// FileAttachmentID and Filename say what there is to download.
+func (s Server) SecretMetadataOnly(id int) (*Secret, error) {
+	return s.readSecret(context.Background(), id)
+}
+
+// SecretFieldValue gets the value of the field with the given slug on the
+// secret with the given id, without fetching the rest of the secret. The value
+// of a file field is the contents of its file attachment.
+func (s Server) SecretFieldValue(id int, slug string) (string, error) {
+	path := fmt.Sprintf("%d/fields/%s", id, slug)
+
+	data, err := s.accessResource("GET", resource, path, nil)
+	if err != nil {
+		return "", err
+	}
+
+	// the server returns the values of text fields as JSON strings, and file
+	// attachments as they are
+	var value string
+	if json.Unmarshal(data, &value) == nil {
+		return value, nil
+	}
+	return string(data), nil
+}
+
+// SecretFileAttachment streams the file attachment of the field with the given
+// slug on the secret with the given id to w, rather than holding it in memory,
+// and returns the number of bytes written.
+func (s Server) SecretFileAttachment(id int, slug string, w io.Writer) (int64, error) {
+	return s.downloadFile(context.Background(), id, slug, w)
+}
+
+// UploadSecretFileAttachment uploads the contents read from r as the file with
+// the given filename to the field with the given slug on the secret with the
+// given id, and returns the updated secret. An error is returned if the field
+// is not a file field on the secret's template.
+func (s Server) UploadSecretFileAttachment(id int, slug string, filename string, r io.Reader) (*Secret, error) {
+	secret, err := s.readSecret(context.Background(), id)
+	if err != nil {
+		return nil, err
+	}
+	template, err := s.SecretTemplate(secret.SecretTemplateID)
+	if err != nil {
+		return nil, err
+	}
+	if field, found := template.GetField(slug); !found || !field.IsFile {
+		return nil, fmt.Errorf("[ERROR] field name '%s' is not a file field on the secret template with id '%d'", slug, template.ID)
+	}
+
+	if err := s.uploadFileContents(id, slug, filename, r); err != nil {
+		return nil, err
+	}
+
+	return s.Secret(id)
+}
+
+// SecretVersions returns the summaries of the past versions of the secret with
+// the given id
+func (s Server) SecretVersions(id int) ([]SecretVersion, error) {
+	versions := make([]SecretVersion, 0)
+
+	err := s.eachPage(context.Background(), resource, fmt.Sprintf("%d/versions", id), nil, func(record json.RawMessage) error {
+		var version SecretVersion
+		if err := json.Unmarshal(record, &version); err != nil {
+			return err
+		}
+		versions = append(versions, version)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// SecretVersion gets the given version of the secret with the given id, as it
+// was at the time. Its file attachments are not downloaded.
+func (s Server) SecretVersion(id, version int) (*Secret, error) {
+	secret := new(Secret)
+	path := fmt.Sprintf("%d/versions/%d", id, version)
+
+	if data, err := s.accessResource("GET", resource, path, nil); err == nil {
+		if err = s.unmarshal(data, secret); err != nil {
+			s.logger().Errorf("error parsing response from /%s/%s: %s", resource, path, redactBody(data))
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
 	return secret, nil
 }
 
+// SecretAudit returns the audit log of the secret with the given id, newest
+// first
+func (s Server) SecretAudit(id int) ([]SecretAuditEntry, error) {
+	return s.SecretAuditBetween(id, time.Time{}, time.Time{})
+}
+
+// SecretAuditBetween returns the entries in the audit log of the secret with
+// the given id that were recorded from the given time until the given time,
+// both inclusive, newest first. A zero from or until leaves the range
+// unbounded at that end. The range is sent to the server, and the pages stop
+// being read at the first entry recorded before from.
+func (s Server) SecretAuditBetween(id int, from, until time.Time) ([]SecretAuditEntry, error) {
+	entries := make([]SecretAuditEntry, 0)
+	query := url.Values{
+		"paging.sortBy[0].name":      {"dateRecorded"},
+		"paging.sortBy[0].direction": {"desc"},
+	}
+	if !from.IsZero() {
+		query.Set("paging.filter.startDate", from.UTC().Format(time.RFC3339Nano))
+	}
+	if !until.IsZero() {
+		query.Set("paging.filter.endDate", until.UTC().Format(time.RFC3339Nano))
+	}
+
+	err := s.eachPage(context.Background(), resource, fmt.Sprintf("%d/audits", id), query, func(record json.RawMessage) error {
+		var entry SecretAuditEntry
+		if err := json.Unmarshal(record, &entry); err != nil {
+			return err
+		}
+		// the entries are newest first, so the rest are older still
+		if !from.IsZero() && entry.DateRecorded.Before(from) {
+			return errStopPaging
+		}
+		if !until.IsZero() && entry.DateRecorded.After(until) {
+			return nil
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// SecretFieldHistory returns the past changes to the field with the given slug
+// on the secret with the given id
+func (s Server) SecretFieldHistory(id int, slug string) ([]FieldChange, error) {
+	changes := make([]FieldChange, 0)
+
+	err := s.eachPage(context.Background(), resource, fmt.Sprintf("%d/fields/%s/history", id, slug), nil, func(record json.RawMessage) error {
+		var change FieldChange
+		if err := json.Unmarshal(record, &change); err != nil {
+			return err
+		}
+		changes = append(changes, change)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// readSecret gets the secret with id without downloading its file attachments
+func (s Server) readSecret(ctx context.Context, id int) (*Secret, error) {
+	secret, _, err := s.readSecretRaw(ctx, id, nil)
+	return secret, err
+}
+
+// readSecretRaw is readSecret with the given query parameters, if any, that
+// also returns the body of the response
+func (s Server) readSecretRaw(ctx context.Context, id int, query url.Values) (*Secret, []byte, error) {
+	secret := new(Secret)
+	path := strconv.Itoa(id)
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	data, err := s.accessResourceWithContext(ctx, "GET", resource, path, nil)
+	if err != nil {
+		return nil, nil, s.restrictionError(ctx, id, RestrictedArgs{}, err)
+	}
+	if err = s.unmarshal(data, secret); err != nil {
+		s.logger().Errorf("error parsing response from /%s/%s: %s", resource, path, redactBody(data))
+		return nil, nil, err
+	}
+
+	return secret, data, nil
+}
+
 // Secret gets the secret with id from the Secret Server of the given tenant
 func (s Server) Secrets(searchText, field string) ([]Secret, error) {
 	searchResult := new(SearchResult)
 	if data, err := s.searchResources(resource, searchText, field); err == nil {
 		if err = json.Unmarshal(data, searchResult); err != nil {
-			log.Printf("[ERROR] error parsing response from /%s/%s: %q", resource, searchText, data)
+			s.logger().Errorf("error parsing response from /%s/%s: %s", resource, searchText, redactBody(data))
 			return nil, err
 		}
 	} else {
@@ -102,11 +452,380 @@ func (s Server) Secrets(searchText, field string) ([]Secret, error) {
 	return secrets, nil
 }
 
+// SearchSecrets returns a summary of every secret with a name or field value
+// that contains the given text, fetching as many pages of results as it takes.
+func (s Server) SearchSecrets(text string) ([]SecretSummary, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("[ERROR] the search text must not be empty")
+	}
+
+	return s.searchAllSecretSummaries(context.Background(), searchTextFilter(text))
+}
+
+// SecretSearchScope limits a search to the secrets that the authenticated user
+// has marked as favorites or has used recently
+type SecretSearchScope string
+
+// The scopes of a search with SearchSecretsWithOptions
+const (
+	SearchScopeAll       SecretSearchScope = "All"
+	SearchScopeRecent    SecretSearchScope = "Recent"
+	SearchScopeFavorites SecretSearchScope = "Favorites"
+)
+
+// SecretSearchOptions narrow, or widen, the secrets that
+// SearchSecretsWithOptions finds
+type SecretSearchOptions struct {
+	// Scope defaults to SearchScopeAll
+	Scope SecretSearchScope
+	// OnlySharedWithMe keeps only the secrets that others have shared with
+	// the authenticated user, and OnlyOwned only those that the user owns
+	OnlySharedWithMe, OnlyOwned bool
+	// OnlyRPCEnabled keeps only the secrets with remote password changing
+	OnlyRPCEnabled bool
+	// IncludeRestricted and IncludeInactive also find the secrets that
+	// require a comment, approval or check-out to view, and those that have
+	// been deleted, respectively
+	IncludeRestricted, IncludeInactive bool
+}
+
+// filter returns the search filter for the given text with the options
+func (o SecretSearchOptions) filter(text string) url.Values {
+	filter := url.Values{}
+	if text != "" {
+		filter = searchTextFilter(text)
+	}
+	if o.Scope != "" {
+		filter.Set("paging.filter.scope", string(o.Scope))
+	}
+	if o.OnlySharedWithMe {
+		filter.Set("paging.filter.onlySharedWithMe", "true")
+	}
+	if o.OnlyOwned {
+		filter.Set("paging.filter.permissionRequired", "Owner")
+	}
+	if o.OnlyRPCEnabled {
+		filter.Set("paging.filter.onlyRPCEnabled", "true")
+	}
+	if o.IncludeRestricted {
+		filter.Set("paging.filter.includeRestricted", "true")
+	}
+	if o.IncludeInactive {
+		filter.Set("paging.filter.includeInactive", "true")
+	}
+	return filter
+}
+
+// SearchSecretsWithOptions is SearchSecrets with the given options, e.g. to
+// find the secrets that the authenticated user has used recently. The text may
+// be empty, to find every secret within the options.
+func (s Server) SearchSecretsWithOptions(text string, options SecretSearchOptions) ([]SecretSummary, error) {
+	return s.searchAllSecretSummaries(context.Background(), options.filter(text))
+}
+
+// SecretNameMatch controls how SecretNameToIDMatching compares the names of the
+// secrets that the server's search returns with the name being resolved
+type SecretNameMatch struct {
+	// Exact keeps only the secrets whose whole name is the name being
+	// resolved, rather than every secret that the search returns
+	Exact bool
+	// CaseInsensitive ignores case when Exact compares names
+	CaseInsensitive bool
+}
+
+// SecretNotFoundError is returned when no secret has the given name, within
+// the folder with the given path if there is one
+type SecretNotFoundError struct {
+	Name, FolderPath string
+}
+
+func (e *SecretNotFoundError) Error() string {
+	if e.FolderPath != "" {
+		return fmt.Sprintf("no secret with name '%s' in the folder with path '%s'", e.Name, e.FolderPath)
+	}
+	return fmt.Sprintf("no secret with name '%s'", e.Name)
+}
+
+// MultipleSecretsFoundError is returned when more than one secret has the
+// given name, within the folder with the given path if there is one
+type MultipleSecretsFoundError struct {
+	IDs              []int
+	Name, FolderPath string
+}
+
+func (e *MultipleSecretsFoundError) Error() string {
+	if e.FolderPath != "" {
+		return fmt.Sprintf("%d secrets with name '%s' in the folder with path '%s': %v", len(e.IDs), e.Name, e.FolderPath, e.IDs)
+	}
+	return fmt.Sprintf("%d secrets with name '%s': %v", len(e.IDs), e.Name, e.IDs)
+}
+
+// SecretNameToID returns the ID of the one secret whose name is exactly the
+// given name, among every page of the secrets that the server finds when
+// searching for it, which include those whose names merely contain it. It
+// returns a *SecretNotFoundError if there is no such secret and a
+// *MultipleSecretsFoundError if there is more than one.
+func (s Server) SecretNameToID(name string) (int, error) {
+	return s.SecretNameToIDWithContext(context.Background(), name)
+}
+
+// SecretNameToIDWithContext is SecretNameToID with a ctx that governs the
+// requests
+func (s Server) SecretNameToIDWithContext(ctx context.Context, name string) (int, error) {
+	return s.secretNameToID(ctx, name, SecretNameMatch{Exact: true})
+}
+
+// SecretNameToIDMatching is SecretNameToID with the secrets that the server
+// finds filtered by the given match rather than by their exact name, e.g. to
+// ignore case, or, with a zero match, to keep every secret that it finds.
+func (s Server) SecretNameToIDMatching(name string, match SecretNameMatch) (int, error) {
+	return s.secretNameToID(context.Background(), name, match)
+}
+
+// secretNameToID returns the ID of the one secret with the given name that is
+// kept by the given match
+func (s Server) secretNameToID(ctx context.Context, name string, match SecretNameMatch) (int, error) {
+	summaries, err := s.searchAllSecretSummaries(ctx, searchTextFilter(name))
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]int, 0)
+	for _, summary := range summaries {
+		if match.Exact {
+			if match.CaseInsensitive && !strings.EqualFold(summary.Name, name) ||
+				!match.CaseInsensitive && summary.Name != name {
+				continue
+			}
+		}
+		ids = append(ids, summary.ID)
+	}
+
+	switch len(ids) {
+	case 0:
+		return 0, &SecretNotFoundError{Name: name}
+	case 1:
+		return ids[0], nil
+	default:
+		return 0, &MultipleSecretsFoundError{IDs: ids, Name: name}
+	}
+}
+
+// SecretFieldByName returns the value of the field with the given name or slug
+// on the secret with the given name, as resolved by SecretNameToID. It returns
+// a *SecretNotFoundError or a *MultipleSecretsFoundError if the name does not
+// resolve to exactly one secret, and a *FieldNotFoundError if the secret has
+// no such field.
+func (s Server) SecretFieldByName(secretName, fieldName string) (string, error) {
+	id, err := s.SecretNameToID(secretName)
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := s.Secret(id)
+	if err != nil {
+		return "", err
+	}
+
+	value, found := secret.Field(fieldName)
+	if !found {
+		return "", &FieldNotFoundError{SecretID: id, FieldName: fieldName}
+	}
+	return value, nil
+}
+
+// SecretByPath gets the secret with the given path, that is, the path of its
+// folder followed by its name, e.g. \Prod\DB\app-user, which may be separated
+// by either \ or /. A path with no folder part, e.g. \app-user, names a secret
+// in the root folder, as it does for CreateSecretInPath. The folder path is
+// matched ignoring case, as FolderNameToID matches it, and the name exactly,
+// as SecretNameToID matches it. It returns a *FolderNotFoundError or a
+// *MultipleFoldersFoundError if the folder cannot be resolved, and a
+// *SecretNotFoundError or a *MultipleSecretsFoundError if the folder does not
+// hold exactly one secret with the name.
+func (s Server) SecretByPath(path string) (*Secret, error) {
+	secretPath := normalizeFolderPath(path)
+	separator := strings.LastIndex(secretPath, `\`)
+	folderPath, name := secretPath[:separator], secretPath[separator+1:]
+	if name == "" {
+		return nil, fmt.Errorf("[ERROR] the secret path '%s' must name a secret", path)
+	}
+
+	folderID := -1
+	if folderPath == "" {
+		folderPath = `\`
+	} else {
+		var err error
+		if folderID, err = s.FolderNameToID(folderPath); err != nil {
+			return nil, err
+		}
+	}
+
+	filter := searchTextFilter(name)
+	filter.Set("paging.filter.folderId", strconv.Itoa(folderID))
+	summaries, err := s.searchAllSecretSummaries(context.Background(), filter)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0)
+	for _, summary := range summaries {
+		if summary.FolderID == folderID && summary.Name == name {
+			ids = append(ids, summary.ID)
+		}
+	}
+
+	switch len(ids) {
+	case 0:
+		return nil, &SecretNotFoundError{Name: name, FolderPath: folderPath}
+	case 1:
+		return s.Secret(ids[0])
+	default:
+		return nil, &MultipleSecretsFoundError{IDs: ids, Name: name, FolderPath: folderPath}
+	}
+}
+
+// AllSecrets returns the summaries of every secret that the authenticated user
+// can see, fetching as many pages of results as it takes. Use AllSecretsFunc
+// rather than holding them all in memory when there are many.
+func (s Server) AllSecrets() ([]SecretSummary, error) {
+	return s.searchAllSecretSummaries(context.Background(), url.Values{})
+}
+
+// AllSecretsFunc calls fn with the summary of every secret that the
+// authenticated user can see, a page of results at a time. It stops, and
+// returns the error, if fn returns one.
+func (s Server) AllSecretsFunc(fn func(SecretSummary) error) error {
+	return s.eachSecretSummary(context.Background(), url.Values{}, fn)
+}
+
+// searchAllSecretSummaries returns the summaries of the secrets that match the
+// given filter, fetching as many pages of results as it takes.
+func (s Server) searchAllSecretSummaries(ctx context.Context, filter url.Values) ([]SecretSummary, error) {
+	summaries := make([]SecretSummary, 0)
+	err := s.eachSecretSummary(ctx, filter, func(summary SecretSummary) error {
+		summaries = append(summaries, summary)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+// eachSecretSummary calls fn with the summary of each secret that matches the
+// given filter, fetching one page of results at a time, until fn returns an
+// error.
+func (s Server) eachSecretSummary(ctx context.Context, filter url.Values, fn func(SecretSummary) error) error {
+	query := url.Values{"paging.filter.doNotCalculateTotal": {"true"}}
+	for key, values := range filter {
+		query[key] = values
+	}
+
+	return s.eachPage(ctx, resource, "", query, func(record json.RawMessage) error {
+		var summary SecretSummary
+		if err := json.Unmarshal(record, &summary); err != nil {
+			return err
+		}
+		return fn(summary)
+	})
+}
+
+// SearchSecretsPaged returns one page of the summaries of the secrets with a
+// name or field value that contains the given text, skipping the first skip
+// records and taking at most take of them, along with the total number of
+// secrets that match. If take is not positive, the page holds up to 100
+// records, the page size that SearchSecrets uses. Pages hold at most as many
+// records as the server allows, regardless of take, so a page can be shorter
+// than take while more records follow; advance skip by the number of records
+// returned and stop once it reaches the total, not on a short page, as
+// SearchSecrets does with the server's HasNext.
+func (s Server) SearchSecretsPaged(text string, skip, take int) ([]SecretSummary, int, error) {
+	if take <= 0 {
+		take = searchPageSize
+	}
+	page, err := s.searchSecretSummaries(context.Background(), searchTextFilter(text), skip, take, true)
+	if err != nil {
+		return nil, 0, err
+	}
+	return page.Records, page.Total, nil
+}
+
+// searchTextFilter returns the search filter for the secrets that contain the
+// given text
+func searchTextFilter(text string) url.Values {
+	return url.Values{"paging.filter.searchText": {text}}
+}
+
+// searchSecretSummaries returns the page of secrets that match the given
+// filter, skipping the first skip records and taking at most take of them.
+// The total number of matches is only counted when calculateTotal is true,
+// since it makes the search slower.
+func (s Server) searchSecretSummaries(ctx context.Context, filter url.Values, skip, take int, calculateTotal bool) (*secretSummaryPage, error) {
+	query := url.Values{
+		"paging.filter.doNotCalculateTotal": {strconv.FormatBool(!calculateTotal)},
+		"paging.skip":                       {strconv.Itoa(skip)},
+		"paging.take":                       {strconv.Itoa(take)},
+	}
+	for key, values := range filter {
+		query[key] = values
+	}
+	page := new(secretSummaryPage)
+
+	if data, err := s.accessResourceWithContext(ctx, "GET", resource, "?"+query.Encode(), nil); err == nil {
+		if err = json.Unmarshal(data, page); err != nil {
+			s.logger().Errorf("error parsing response from /%s?%s: %s", resource, query.Encode(), redactBody(data))
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// CreateSecret creates a new secret from the given secret and returns the
+// secret as it was stored by the server, including its server-assigned ID.
+// Fields that the template defines as file fields are uploaded separately once
+// the secret has been created.
 func (s Server) CreateSecret(secret Secret) (*Secret, error) {
 	return s.writeSecret(secret, "POST", "/")
 }
 
+// CreateSecretInPath creates the given secret in the folder with the given
+// path, e.g. \Prod\DB, which may be separated by either \ or /, and returns
+// it as CreateSecret does. If createMissingFolders is true, the folder, and
+// any of its parents, are created if they do not exist. A
+// *FolderResolutionError is returned if the folder cannot be found or created,
+// in which case no secret is created; errors creating the secret itself are
+// returned as CreateSecret returns them.
+func (s Server) CreateSecretInPath(path string, secret Secret, createMissingFolders bool) (*Secret, error) {
+	var folderID int
+	var err error
+	if createMissingFolders {
+		folderID, err = s.ensureFolderPath(path)
+	} else if normalizeFolderPath(path) == `\` {
+		folderID = -1
+	} else {
+		folderID, err = s.FolderNameToID(path)
+	}
+	if err != nil {
+		return nil, &FolderResolutionError{Path: path, Err: err}
+	}
+
+	secret.FolderID = folderID
+	return s.CreateSecret(secret)
+}
+
+// UpdateSecret replaces the secret with the ID of the given secret, including
+// all of its fields, and returns the secret as it was stored by the server. An
+// error is returned if the given secret has no ID. File fields are uploaded
+// only when their contents or filename differ from what is stored, so a secret
+// that was read with Secret can be updated without rewriting its attachments.
 func (s Server) UpdateSecret(secret Secret) (*Secret, error) {
+	if secret.ID == 0 {
+		return nil, fmt.Errorf("[ERROR] the secret named '%s' has no ID so it cannot be updated", secret.Name)
+	}
 	if secret.SshKeyArgs != nil && (secret.SshKeyArgs.GenerateSshKeys || secret.SshKeyArgs.GeneratePassphrase) {
 		err := fmt.Errorf("[ERROR] SSH key and passphrase generation is only supported during secret creation. "+
 			"Could not update the secret named '%s'", secret.Name)
@@ -144,6 +863,18 @@ func (s Server) writeSecret(secret Secret, method string, path string) (*Secret,
 		secret.Fields = generalFields
 	}
 
+	// When updating, leave the file fields that have not changed alone,
+	// rather than uploading the contents that Secret downloaded back over
+	// the attachment. Read them from the server rather than the cache, which
+	// may not hold the files that it has now.
+	if method == "PUT" && len(fileFields) > 0 {
+		stored, err := s.Secret(secret.ID, WithoutCache())
+		if err != nil {
+			return nil, err
+		}
+		fileFields = stored.changedFiles(fileFields, s.logger())
+	}
+
 	// If no SSH generation is called for, remove the SshKeyArgs value.
 	// Simply having the value in the Secret object causes the
 	// server to throw an error if the template is not geared towards
@@ -162,9 +893,19 @@ func (s Server) writeSecret(secret Secret, method string, path string) (*Secret,
 		secret.Fields = make([]SecretField, 0)
 	}
 
+	if s.DryRun {
+		// there is nothing to read back, so return what would have been
+		// written, with the file fields that would have been uploaded
+		if _, err := s.accessResource(method, resource, path, secret); err != nil {
+			return nil, err
+		}
+		secret.Fields = append(secret.Fields, fileFields...)
+		return &secret, nil
+	}
+
 	if data, err := s.accessResource(method, resource, path, secret); err == nil {
-		if err = json.Unmarshal(data, writtenSecret); err != nil {
-			log.Printf("[ERROR] error parsing response from /%s: %q", resource, data)
+		if err = s.unmarshal(data, writtenSecret); err != nil {
+			s.logger().Errorf("error parsing response from /%s: %s", resource, redactBody(data))
 			return nil, err
 		}
 	} else {
@@ -178,20 +919,386 @@ func (s Server) writeSecret(secret Secret, method string, path string) (*Secret,
 	return s.Secret(writtenSecret.ID)
 }
 
+// fieldMod, fieldMods and secretFieldsPatch make up the body of a PATCH of the
+// fields of a secret, which sets the Value of each field that is Dirty
+type fieldMod struct {
+	Slug  string
+	Dirty bool
+	Value interface{}
+}
+
+type fieldMods struct {
+	SecretFields []fieldMod
+}
+
+type secretFieldsPatch struct {
+	Data fieldMods
+}
+
+// UpdateSecretField sets the value of the field with the given name or slug on
+// the secret with the given id, leaving its other fields as they are, and
+// returns the updated secret. A *FieldNotFoundError is returned if the secret
+// has no such field.
+func (s Server) UpdateSecretField(id int, fieldSlug, value string) (*Secret, error) {
+	secret, err := s.readSecret(context.Background(), id)
+	if err != nil {
+		return nil, err
+	}
+
+	slug, found := secret.fieldSlug(fieldSlug)
+	if !found {
+		return nil, &FieldNotFoundError{SecretID: id, FieldName: fieldSlug}
+	}
+
+	path := fmt.Sprintf("%d/fields/%s", id, slug)
+	input := struct{ Value string }{Value: value}
+	if _, err := s.accessResource("PUT", resource, path, input); err != nil {
+		return nil, err
+	}
+
+	return s.Secret(id)
+}
+
+// UpdateSecretFields sets the values of the fields with the names or slugs of
+// the given updates on the secret with the given id, leaving its other fields
+// as they are, and returns the updated secret. The fields are updated together
+// in one request, so either all of them are or, if it fails, none are. A
+// *FieldNotFoundError is returned, before anything is updated, if the secret
+// has no field with one of the names. File fields cannot be updated this way;
+// use UpdateSecret for them.
+func (s Server) UpdateSecretFields(id int, updates map[string]string) (*Secret, error) {
+	if len(updates) == 0 {
+		return nil, fmt.Errorf("[ERROR] no fields to update on the secret with id '%d'", id)
+	}
+
+	secret, err := s.readSecret(context.Background(), id)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(updates))
+	for name := range updates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	mods := make([]fieldMod, 0, len(names))
+	for _, name := range names {
+		field, found := secret.GetField(name)
+		if !found {
+			return nil, &FieldNotFoundError{SecretID: id, FieldName: name}
+		}
+		if field.IsFile {
+			return nil, fmt.Errorf("[ERROR] field '%s' on the secret with id '%d' is a file field, which UpdateSecretFields cannot update", name, id)
+		}
+		mods = append(mods, fieldMod{Slug: field.Slug, Dirty: true, Value: updates[name]})
+	}
+
+	path := fmt.Sprintf("%d/general", id)
+	input := secretFieldsPatch{Data: fieldMods{SecretFields: mods}}
+	if _, err := s.accessResource("PATCH", resource, path, input); err != nil {
+		return nil, err
+	}
+
+	return s.Secret(id)
+}
+
+// MoveSecret moves the secret with the given id into the folder with the given
+// id, or to the root if it is -1, and returns the moved secret. An error is
+// returned if there is no such folder.
+func (s Server) MoveSecret(id, targetFolderID int) (*Secret, error) {
+	if targetFolderID != -1 {
+		if _, err := s.Folder(targetFolderID); err != nil {
+			return nil, fmt.Errorf("[ERROR] cannot move the secret with id '%d' to the folder with id '%d': %w", id, targetFolderID, err)
+		}
+	}
+
+	type folderMod struct {
+		Dirty bool
+		Value int
+	}
+
+	type generalMods struct {
+		Folder folderMod
+	}
+
+	type secretPatch struct {
+		Data generalMods
+	}
+
+	path := fmt.Sprintf("%d/general", id)
+	input := secretPatch{Data: generalMods{Folder: folderMod{Dirty: true, Value: targetFolderID}}}
+	if _, err := s.accessResource("PATCH", resource, path, input); err != nil {
+		return nil, err
+	}
+
+	return s.Secret(id)
+}
+
+// SetFavorite adds the secret with the given id to the favorites of the
+// authenticated user, or removes it from them. The server's *APIError is
+// returned if the user cannot access the secret.
+func (s Server) SetFavorite(id int, favorite bool) error {
+	input := struct{ IsFavorite bool }{IsFavorite: favorite}
+	_, err := s.accessResource("POST", resource, fmt.Sprintf("%d/favorite", id), input)
+	return err
+}
+
+// CheckOutSecret checks out the secret with the given id, so that no one else
+// can access it until it is checked in, and returns it. An error is returned
+// if check-out is not enabled for the secret.
+func (s Server) CheckOutSecret(id int) (*Secret, error) {
+	secret, err := s.readSecret(context.Background(), id)
+	if err != nil {
+		return nil, err
+	}
+	if !secret.CheckOutEnabled {
+		return nil, fmt.Errorf("[ERROR] check-out is not enabled for the secret with id '%d'", id)
+	}
+
+	if _, err := s.accessResource("POST", resource, fmt.Sprintf("%d/check-out", id), nil); err != nil {
+		return nil, err
+	}
+
+	return s.Secret(id)
+}
+
+// SecretSummaryByID returns the summary of the secret with the given id, which
+// can be read even when the secret itself cannot without a comment, a double
+// lock password or an approved access request
+func (s Server) SecretSummaryByID(id int) (*SecretSummary, error) {
+	return s.secretSummary(context.Background(), id)
+}
+
+// secretSummary returns the summary of the secret with the given id
+func (s Server) secretSummary(ctx context.Context, id int) (*SecretSummary, error) {
+	summary := new(SecretSummary)
+	path := fmt.Sprintf("%d/summary", id)
+
+	if data, err := s.accessResourceWithContext(ctx, "GET", resource, path, nil); err == nil {
+		if err = json.Unmarshal(data, summary); err != nil {
+			s.logger().Errorf("error parsing response from /%s/%s: %s", resource, path, redactBody(data))
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// CheckOutInfo is the check-out state of a secret; who has it checked out, if
+// anyone, and until when
+type CheckOutInfo struct {
+	CheckedOut      bool
+	UserID          int
+	UserDisplayName string
+	// IntervalMinutes is how long a check-out of the secret lasts, and
+	// MinutesRemaining how many whole minutes are left of the current one,
+	// as the server reports them, after which the secret is checked in
+	// automatically. MinutesRemaining is zero if it is not checked out.
+	IntervalMinutes, MinutesRemaining int
+	// ExpiresAt is when the current check-out ends, approximately; it is the
+	// client's clock plus MinutesRemaining, so it can be up to a minute early,
+	// and is zero if the secret is not checked out
+	ExpiresAt time.Time
+}
+
+// CheckOutStatus returns the check-out state of the secret with the given id
+func (s Server) CheckOutStatus(id int) (*CheckOutInfo, error) {
+	summary := struct {
+		CheckedOut                                                        bool
+		CheckOutUserID, CheckOutIntervalMinutes, CheckOutMinutesRemaining int
+		CheckOutUserDisplayName                                           string
+	}{}
+	path := fmt.Sprintf("%d/summary", id)
+
+	if data, err := s.accessResource("GET", resource, path, nil); err == nil {
+		if err = json.Unmarshal(data, &summary); err != nil {
+			s.logger().Errorf("error parsing response from /%s/%s: %s", resource, path, redactBody(data))
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
+	info := &CheckOutInfo{
+		CheckedOut:      summary.CheckedOut,
+		IntervalMinutes: summary.CheckOutIntervalMinutes,
+	}
+	if summary.CheckedOut {
+		info.UserID = summary.CheckOutUserID
+		info.UserDisplayName = summary.CheckOutUserDisplayName
+		info.MinutesRemaining = summary.CheckOutMinutesRemaining
+		info.ExpiresAt = time.Now().Add(time.Duration(summary.CheckOutMinutesRemaining) * time.Minute)
+	}
+	return info, nil
+}
+
+// CheckInSecret checks in the secret with the given id. It does nothing if the
+// secret is not checked out.
+func (s Server) CheckInSecret(id int) error {
+	secret, err := s.readSecret(context.Background(), id)
+	if err != nil {
+		return err
+	}
+	if !secret.CheckedOut {
+		s.logger().Debugf("the secret with id '%d' is not checked out", id)
+		return nil
+	}
+
+	_, err = s.accessResource("POST", resource, fmt.Sprintf("%d/check-in", id), nil)
+	return err
+}
+
+// DeleteSecret deletes the secret with the given id. Secret Server deactivates
+// deleted secrets rather than removing them, so they can be brought back with
+// RestoreSecret. An *InactiveSecretError is returned if the secret has already
+// been deleted.
 func (s Server) DeleteSecret(id int) error {
 	_, err := s.accessResource("DELETE", resource, strconv.Itoa(id), nil)
+	if err != nil {
+		// the server does not say why a delete failed, so check whether the
+		// secret was already inactive, and report the original error if not
+		summary := struct{ Active bool }{Active: true}
+		if data, sErr := s.accessResource("GET", resource, fmt.Sprintf("%d/summary", id), nil); sErr == nil {
+			if json.Unmarshal(data, &summary) == nil && !summary.Active {
+				return &InactiveSecretError{ID: id}
+			}
+		}
+	}
 	return err
 }
 
+// RestoreSecret reactivates the deleted secret with the given id and returns it
+func (s Server) RestoreSecret(id int) (*Secret, error) {
+	if _, err := s.accessResource("PUT", resource, fmt.Sprintf("%d/restore", id), nil); err != nil {
+		return nil, err
+	}
+	return s.Secret(id)
+}
+
+// String describes the secret and its fields, masking the values of password
+// and file fields, so that printing or logging the secret does not disclose
+// them. Their values are still in the fields' ItemValue, and are returned by
+// Field.
+func (s Secret) String() string {
+	fields := make([]string, len(s.Fields))
+	for i, field := range s.Fields {
+		fields[i] = field.String()
+	}
+	return fmt.Sprintf("Secret{ID: %d, Name: %q, FolderID: %d, SecretTemplateID: %d, Fields: [%s]}",
+		s.ID, s.Name, s.FolderID, s.SecretTemplateID, strings.Join(fields, ", "))
+}
+
+// GoString is String, so that the values stay masked when the secret is
+// printed with %#v.
+func (s Secret) GoString() string {
+	return s.String()
+}
+
+// String describes the field as its slug, or its name if it has none, and its
+// value, which is masked if the field is a password or a file.
+func (f SecretField) String() string {
+	name := f.Slug
+	if name == "" {
+		name = f.FieldName
+	}
+	if f.IsPassword || f.IsFile {
+		return fmt.Sprintf("%s=%s", name, redacted)
+	}
+	return fmt.Sprintf("%s=%q", name, f.ItemValue)
+}
+
+// GoString is String, so that the value stays masked when the field is
+// printed with %#v.
+func (f SecretField) GoString() string {
+	return f.String()
+}
+
+// WriteSafeCopy returns a copy of the secret that can be sent back to the
+// server as it is. The copy has its own Fields, without the file fields, whose
+// ItemValue holds the contents that Secret downloaded, and without the values
+// that only the server sets, so updating a secret with it leaves its file
+// attachments and check-out state alone.
+func (s Secret) WriteSafeCopy() Secret {
+	copied := s
+	copied.SecretTemplateName = ""
+	copied.CheckedOut = false
+	copied.IsDoubleLock = false
+	copied.IsRestricted = false
+	copied.RequiresApprovalForAccess = false
+	copied.SshKeyArgs = nil
+	copied.LastModified = nil
+	copied.Created = nil
+	copied.LastModifiedBy = ""
+	copied.Fields = make([]SecretField, 0, len(s.Fields))
+
+	for _, field := range s.Fields {
+		if field.IsFile {
+			continue
+		}
+		field.FileAttachmentID = 0
+		field.FieldDescription = ""
+		field.Filename = ""
+		copied.Fields = append(copied.Fields, field)
+	}
+
+	return copied
+}
+
 // Field returns the value of the field with the name fieldName
 func (s Secret) Field(fieldName string) (string, bool) {
 	for _, field := range s.Fields {
 		if fieldName == field.FieldName || fieldName == field.Slug {
-			log.Printf("[DEBUG] field with name '%s' matches '%s'", field.FieldName, fieldName)
 			return field.ItemValue, true
 		}
 	}
-	log.Printf("[DEBUG] no matching field for name '%s' in secret '%s'", fieldName, s.Name)
+	return "", false
+}
+
+// GetField returns the field with the name or slug fieldName, and whether there
+// is such a field. The field is not a copy; it shares the secret's Fields, so
+// changes to it change the secret.
+func (s Secret) GetField(fieldName string) (*SecretField, bool) {
+	for index, field := range s.Fields {
+		if fieldName == field.FieldName || fieldName == field.Slug {
+			return &s.Fields[index], true
+		}
+	}
+	return nil, false
+}
+
+// FieldMap returns the secret's fields by slug. Unlike GetField, the fields
+// are copies, so changing them does not change the secret; use SetField for
+// that.
+func (s Secret) FieldMap() map[string]SecretField {
+	fields := make(map[string]SecretField, len(s.Fields))
+	for _, field := range s.Fields {
+		fields[field.Slug] = field
+	}
+	return fields
+}
+
+// SetField sets the value of the field with the name fieldName, and returns
+// whether there is such a field
+func (s *Secret) SetField(fieldName, value string) bool {
+	for index, field := range s.Fields {
+		if fieldName == field.FieldName || fieldName == field.Slug {
+			s.Fields[index].ItemValue = value
+			return true
+		}
+	}
+	return false
+}
+
+// fieldSlug returns the slug of the field with the name or slug fieldName
+func (s Secret) fieldSlug(fieldName string) (string, bool) {
+	for _, field := range s.Fields {
+		if fieldName == field.FieldName || fieldName == field.Slug {
+			return field.Slug, true
+		}
+	}
 	return "", false
 }
 
@@ -199,38 +1306,116 @@ func (s Secret) Field(fieldName string) (string, bool) {
 func (s Secret) FieldById(fieldId int) (string, bool) {
 	for _, field := range s.Fields {
 		if fieldId == field.FieldID {
-			log.Printf("[DEBUG] field with name '%s' matches field ID '%d'", field.FieldName, fieldId)
 			return field.ItemValue, true
 		}
 	}
-	log.Printf("[DEBUG] no matching field for ID '%d' in secret '%s'", fieldId, s.Name)
 	return "", false
 }
 
-// updateFiles iterates the list of file fields and if the field's item value is empty,
-// deletes the file, otherwise, uploads the contents of the item value as the new/updated
-// file attachment.
-func (s Server) updateFiles(secretId int, fileFields []SecretField) error {
-	type fieldMod struct {
-		Slug  string
-		Dirty bool
-		Value interface{}
+// FieldInt returns the value of the field with the name fieldName as an int.
+// The bool reports whether there is such a field, and the error whether its
+// value, which may be empty, could not be parsed.
+func (s Secret) FieldInt(fieldName string) (int, bool, error) {
+	value, found := s.Field(fieldName)
+	if !found {
+		return 0, false, nil
+	}
+	number, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, true, fmt.Errorf("[ERROR] the value of field '%s' on secret '%s' is not an integer: %w", fieldName, s.Name, err)
 	}
+	return number, true, nil
+}
 
-	type fieldMods struct {
-		SecretFields []fieldMod
+// FieldBool returns the value of the field with the name fieldName as a bool,
+// accepting the values that strconv.ParseBool does, e.g. "true" and "1". The
+// second bool reports whether there is such a field, and the error whether its
+// value could not be parsed.
+func (s Secret) FieldBool(fieldName string) (bool, bool, error) {
+	value, found := s.Field(fieldName)
+	if !found {
+		return false, false, nil
+	}
+	flag, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		return false, true, fmt.Errorf("[ERROR] the value of field '%s' on secret '%s' is not a boolean: %w", fieldName, s.Name, err)
 	}
+	return flag, true, nil
+}
 
-	type secretPatch struct {
-		Data fieldMods
+// ToEnv returns the values of the secret's fields as environment variables,
+// named after their slugs, uppercased and prefixed with the given prefix and an
+// underscore, e.g. DB_PRIVATE_KEY for the private-key field with the prefix
+// DB. Characters that are not letters or digits are replaced with underscores.
+// File fields hold the contents that Secret downloaded; use ToEnvWithoutFiles
+// to leave them out.
+func (s Secret) ToEnv(prefix string) map[string]string {
+	return s.toEnv(prefix, true)
+}
+
+// ToEnvWithoutFiles is ToEnv without the secret's file fields
+func (s Secret) ToEnvWithoutFiles(prefix string) map[string]string {
+	return s.toEnv(prefix, false)
+}
+
+// toEnv is ToEnv, with the file fields only if includeFiles is true
+func (s Secret) toEnv(prefix string, includeFiles bool) map[string]string {
+	env := make(map[string]string, len(s.Fields))
+	for _, field := range s.Fields {
+		if field.IsFile && !includeFiles {
+			continue
+		}
+		name := envName(field.Slug)
+		if prefix != "" {
+			name = strings.TrimSuffix(envName(prefix), "_") + "_" + name
+		}
+		env[name] = field.ItemValue
 	}
+	return env
+}
+
+// envName returns the given name uppercased, with the characters that are not
+// letters or digits replaced with underscores
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
 
+// addTemplateMetadata sets the metadata of each of the secret's fields from the
+// field of the given template with its FieldID
+func (s *Secret) addTemplateMetadata(template *SecretTemplate) {
+	for index, field := range s.Fields {
+		for _, templateField := range template.Fields {
+			if templateField.SecretTemplateFieldID == field.FieldID {
+				s.Fields[index].IsRequired = templateField.IsRequired
+				s.Fields[index].IsUrl = templateField.IsUrl
+				s.Fields[index].IsList = templateField.IsList
+				s.Fields[index].ListType = templateField.ListType
+				s.Fields[index].PasswordRequirementID = templateField.PasswordRequirementID
+				break
+			}
+		}
+	}
+}
+
+// updateFiles iterates the list of file fields and if the field's item value is empty,
+// deletes the file, otherwise, uploads the contents of the item value as the new/updated
+// file attachment.
+func (s Server) updateFiles(secretId int, fileFields []SecretField) error {
 	for _, element := range fileFields {
 		var path string
 		var input interface{}
 		if element.ItemValue == "" {
 			path = fmt.Sprintf("%d/general", secretId)
-			input = secretPatch{Data: fieldMods{SecretFields: []fieldMod{{Slug: element.Slug, Dirty: true, Value: nil}}}}
+			input = secretFieldsPatch{Data: fieldMods{SecretFields: []fieldMod{{Slug: element.Slug, Dirty: true, Value: nil}}}}
 			if _, err := s.accessResource("PATCH", resource, path, input); err != nil {
 				return err
 			}
@@ -243,6 +1428,30 @@ func (s Server) updateFiles(secretId int, fileFields []SecretField) error {
 	return nil
 }
 
+// changedFiles returns the given file fields whose contents or filename differ
+// from those of the matching field on this secret, logging the others to the
+// given logger.
+func (s Secret) changedFiles(fileFields []SecretField, logger Logger) []SecretField {
+	var changed []SecretField
+
+	for _, fileField := range fileFields {
+		unchanged := false
+		for _, field := range s.Fields {
+			if fileField.FieldID == field.FieldID || fileField.Slug != "" && fileField.Slug == field.Slug {
+				unchanged = fileField.ItemValue == field.ItemValue && fileField.Filename == field.Filename
+				break
+			}
+		}
+		if unchanged {
+			logger.Debugf("file field '%s' is unchanged, leaving it as it is", fileField.Slug)
+		} else {
+			changed = append(changed, fileField)
+		}
+	}
+
+	return changed
+}
+
 // separateFileFields iterates the fields on this secret, and separates them into file
 // fields and non-file fields, using the field definitions in the given template as a
 // guide. File fields are returned as the first output, non file fields as the second
@@ -263,6 +1472,9 @@ func (s Secret) separateFileFields(template *SecretTemplate) ([]SecretField, []S
 		if templateField, found = template.GetField(fieldSlug); !found {
 			return nil, nil, fmt.Errorf("[ERROR] field name '%s' is not defined on the secret template with id '%d'", fieldSlug, template.ID)
 		}
+		if field.IsFile && !templateField.IsFile {
+			return nil, nil, fmt.Errorf("[ERROR] field name '%s' is marked as a file but it is not a file field on the secret template with id '%d'", fieldSlug, template.ID)
+		}
 		if templateField.IsFile {
 			fileFields = append(fileFields, field)
 		} else {
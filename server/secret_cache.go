@@ -0,0 +1,128 @@
+package server
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// secretCache holds up to size of the secrets that have been read, by id, for
+// the SecretCacheTTL, so that copies of a Server share them
+type secretCache struct {
+	mutex   sync.Mutex
+	ttl     time.Duration
+	size    int
+	secrets map[int]cachedSecret
+}
+
+// cachedSecret is a secret in the secretCache and when it expires
+type cachedSecret struct {
+	secret    Secret
+	expiresAt time.Time
+}
+
+// newSecretCache returns an empty secretCache that holds up to size secrets,
+// which expire after the given ttl
+func newSecretCache(ttl time.Duration, size int) *secretCache {
+	return &secretCache{ttl: ttl, size: size, secrets: make(map[int]cachedSecret)}
+}
+
+// get returns a copy of the secret with the given id, if the cache holds it
+// and it has not expired
+func (c *secretCache) get(id int) (*Secret, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	cached, found := c.secrets[id]
+	if !found {
+		return nil, false
+	}
+	if time.Now().After(cached.expiresAt) {
+		delete(c.secrets, id)
+		return nil, false
+	}
+	return copySecret(cached.secret), true
+}
+
+// put adds a copy of the given secret to the cache by the given id, making
+// room for it, if the cache is full, by removing the expired secrets, or
+// failing that, the one that expires first
+func (c *secretCache) put(id int, secret *Secret) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	if _, found := c.secrets[id]; !found && len(c.secrets) >= c.size {
+		oldest := -1
+		for cachedID, cached := range c.secrets {
+			if now.After(cached.expiresAt) {
+				delete(c.secrets, cachedID)
+			} else if oldest == -1 || cached.expiresAt.Before(c.secrets[oldest].expiresAt) {
+				oldest = cachedID
+			}
+		}
+		if len(c.secrets) >= c.size {
+			delete(c.secrets, oldest)
+		}
+	}
+	c.secrets[id] = cachedSecret{secret: *copySecret(*secret), expiresAt: now.Add(c.ttl)}
+}
+
+// remove removes the secrets with the given ids from the cache
+func (c *secretCache) remove(ids ...int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, id := range ids {
+		delete(c.secrets, id)
+	}
+}
+
+// removePath removes the secret whose id the given path of the secrets
+// resource starts with, if it does, e.g. after the path was written to
+func (c *secretCache) removePath(path string) {
+	path = strings.TrimPrefix(path, "/")
+	if end := strings.IndexAny(path, "/?"); end >= 0 {
+		path = path[:end]
+	}
+	if id, err := strconv.Atoi(path); err == nil {
+		c.remove(id)
+	}
+}
+
+// clear removes every secret from the cache
+func (c *secretCache) clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.secrets = make(map[int]cachedSecret)
+}
+
+// copySecret returns a copy of the given secret with its own Fields, and
+// SshKeyArgs and timestamps, so that callers cannot change the cached secret
+func copySecret(secret Secret) *Secret {
+	secret.Fields = append([]SecretField(nil), secret.Fields...)
+	if secret.SshKeyArgs != nil {
+		sshKeyArgs := *secret.SshKeyArgs
+		secret.SshKeyArgs = &sshKeyArgs
+	}
+	if secret.LastModified != nil {
+		lastModified := *secret.LastModified
+		secret.LastModified = &lastModified
+	}
+	if secret.Created != nil {
+		created := *secret.Created
+		secret.Created = &created
+	}
+	return &secret
+}
+
+// ClearSecretCache removes every secret from the cache that the SecretCacheTTL
+// enables, e.g. after the secrets were changed by something other than this
+// SDK
+func (s Server) ClearSecretCache() {
+	if s.secrets != nil {
+		s.secrets.clear()
+	}
+}
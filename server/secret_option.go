@@ -0,0 +1,60 @@
+package server
+
+import "net/url"
+
+// SecretOption changes how Secret gets the secret, e.g. by setting a query
+// parameter of its request, for the parameters of the API that the SDK does
+// not otherwise expose
+type SecretOption func(options *secretOptions)
+
+// secretOptions are what the SecretOptions given to Secret set
+type secretOptions struct {
+	query            url.Values
+	templateMetadata bool
+	bypassCache      bool
+}
+
+// WithInactive gets the secret even if it is inactive, i.e. has been deleted
+func WithInactive() SecretOption {
+	return WithParam("includeInactive", "true")
+}
+
+// WithParam sets the query parameter with the given key to the given value,
+// replacing any value that an earlier option set
+func WithParam(key, value string) SecretOption {
+	return func(options *secretOptions) {
+		options.query.Set(key, value)
+	}
+}
+
+// WithTemplateMetadata fills in the metadata that the secret's template has for
+// each of its fields, such as IsRequired, which the secret itself does not
+// carry. It costs a request for the template, unless the template is cached.
+func WithTemplateMetadata() SecretOption {
+	return func(options *secretOptions) {
+		options.templateMetadata = true
+	}
+}
+
+// WithoutCache reads the secret from the server even if the cache that the
+// SecretCacheTTL enables holds it, and caches it afresh
+func WithoutCache() SecretOption {
+	return func(options *secretOptions) {
+		options.bypassCache = true
+	}
+}
+
+// cacheable returns true if the secret that the options read may be cached,
+// i.e. if they set no query parameters
+func (o secretOptions) cacheable() bool {
+	return len(o.query) == 0
+}
+
+// newSecretOptions returns what the given options set
+func newSecretOptions(opts []SecretOption) secretOptions {
+	options := secretOptions{query: url.Values{}}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// secretPermissionResource is the HTTP URL path component for the secret
+// permissions resource
+const secretPermissionResource = "secret-permissions"
+
+// secretAccessRoleNames are the roles that a secret permission can grant
+var secretAccessRoleNames = []string{"List", "View", "Edit", "Owner"}
+
+// SecretPermission grants a user or a group, identified by UserID or GroupID,
+// access to a secret under the named role, one of List, View, Edit or Owner
+type SecretPermission struct {
+	ID, SecretID, GroupID, UserID             int
+	GroupName, UserName, SecretAccessRoleName string
+}
+
+// SecretPermissions returns the permissions that are set on the secret with
+// the given id
+func (s Server) SecretPermissions(id int) ([]SecretPermission, error) {
+	permissions := make([]SecretPermission, 0)
+	query := url.Values{"paging.filter.secretId": {strconv.Itoa(id)}}
+
+	err := s.eachPage(context.Background(), secretPermissionResource, "", query, func(record json.RawMessage) error {
+		var permission SecretPermission
+		if err := json.Unmarshal(record, &permission); err != nil {
+			return err
+		}
+		permissions = append(permissions, permission)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+// AddSecretPermission shares the secret with the given id with the user or
+// group of the given permission, and returns the permission that was stored.
+// An error is returned if the permission's role is not one of the allowed roles.
+func (s Server) AddSecretPermission(id int, permission SecretPermission) (*SecretPermission, error) {
+	if permission.UserID == 0 && permission.GroupID == 0 {
+		return nil, fmt.Errorf("[ERROR] the secret permission must have a user id or a group id")
+	}
+	valid := false
+	for _, roleName := range secretAccessRoleNames {
+		if permission.SecretAccessRoleName == roleName {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return nil, fmt.Errorf("[ERROR] the secret access role '%s' is not one of %s",
+			permission.SecretAccessRoleName, strings.Join(secretAccessRoleNames, ", "))
+	}
+	permission.SecretID = id
+
+	addedPermission := new(SecretPermission)
+
+	if data, err := s.accessResource("POST", secretPermissionResource, "/", permission); err == nil {
+		if err = s.unmarshal(data, addedPermission); err != nil {
+			s.logger().Errorf("error parsing response from /%s: %s", secretPermissionResource, redactBody(data))
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
+	return addedPermission, nil
+}
+
+// RemoveSecretPermission removes the secret permission with the given id,
+// i.e. the ID of a SecretPermission rather than of the secret
+func (s Server) RemoveSecretPermission(permissionID int) error {
+	_, err := s.accessResource("DELETE", secretPermissionResource, strconv.Itoa(permissionID), nil)
+	return err
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestAddSecretPermission validates that a permission with one of the allowed
+// roles is added, and that one with any other role is rejected without
+// calling the server.
+func TestAddSecretPermission(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.respond("POST", "/api/v1/secret-permissions", http.StatusOK,
+		`{"id": 5, "secretId": 42, "userId": 10, "secretAccessRoleName": "View"}`)
+
+	tss := f.server()
+	permission, err := tss.AddSecretPermission(42, SecretPermission{UserID: 10, SecretAccessRoleName: "View"})
+	if err != nil {
+		t.Fatal("calling server.AddSecretPermission:", err)
+	}
+	if !validate("permission id", 5, permission.ID, t) || !validate("secret id", 42, permission.SecretID, t) {
+		return
+	}
+
+	if _, err := tss.AddSecretPermission(42, SecretPermission{UserID: 10, SecretAccessRoleName: "Admin"}); err == nil {
+		t.Error("expecting an error adding a permission with the role 'Admin'")
+	}
+	validate("permission requests", 1, f.count("POST", "/api/v1/secret-permissions"), t)
+}
+
+// TestSecretPermissions validates that every page of the permissions on a
+// secret is read, following HasNext, and only those on the secret.
+func TestSecretPermissions(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.handle("GET", "/api/v1/secret-permissions", func(w http.ResponseWriter, r *http.Request) {
+		if secretID := r.URL.Query().Get("paging.filter.secretId"); secretID != "42" {
+			t.Errorf("expecting the permissions of secret 42, but found '%s' instead", secretID)
+		}
+		if r.URL.Query().Get("paging.skip") == "0" {
+			w.Write([]byte(`{"records": [{"id": 5, "secretId": 42, "userId": 10, "secretAccessRoleName": "View"}], "hasNext": true}`))
+			return
+		}
+		w.Write([]byte(`{"records": [{"id": 6, "secretId": 42, "groupId": 20, "secretAccessRoleName": "Edit"}], "hasNext": false}`))
+	})
+
+	permissions, err := f.server().SecretPermissions(42)
+	if err != nil {
+		t.Fatal("calling server.SecretPermissions:", err)
+	}
+	if !validate("permissions", 2, len(permissions), t) || !validate("pages", 2, f.count("GET", "/api/v1/secret-permissions"), t) {
+		return
+	}
+	validate("second permission group id", 20, permissions[1].GroupID, t)
+	validate("second permission role", "Edit", permissions[1].SecretAccessRoleName, t)
+}
+
+// TestRemoveSecretPermission validates that a permission is removed by its id.
+func TestRemoveSecretPermission(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.respond("DELETE", "/api/v1/secret-permissions/5", http.StatusOK, `{"id": 5, "objectType": "SecretPermission"}`)
+
+	if err := f.server().RemoveSecretPermission(5); err != nil {
+		t.Fatal("calling server.RemoveSecretPermission:", err)
+	}
+	validate("delete requests", 1, f.count("DELETE", "/api/v1/secret-permissions/5"), t)
+}
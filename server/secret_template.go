@@ -1,10 +1,11 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"strconv"
+	"strings"
 )
 
 // templateResource is the HTTP URL path component for the secret templates resource
@@ -17,29 +18,144 @@ type SecretTemplate struct {
 	Fields []SecretTemplateField
 }
 
-// SecretTemplateField is a field in the secret template
+// SecretTemplateField is a field in the secret template. DefaultValue, if the template has one for the field, is what
+// the server sets an empty field to, and PasswordRequirementID, if it is a password field, is the id of the password
+// requirement that its values must meet.
 type SecretTemplateField struct {
-	SecretTemplateFieldID                                   int
+	SecretTemplateFieldID, PasswordRequirementID            int
 	FieldSlugName, DisplayName, Description, Name, ListType string
+	DefaultValue                                            string
 	IsFile, IsList, IsNotes, IsPassword, IsRequired, IsUrl  bool
 }
 
-// SecretTemplate gets the secret template with id from the Secret Server of the given tenant
+// SecretTemplate gets the secret template with id from the Secret Server of the given tenant. The template of a
+// secret is identified by its SecretTemplateID.
 func (s Server) SecretTemplate(id int) (*SecretTemplate, error) {
+	return s.SecretTemplateWithContext(context.Background(), id)
+}
+
+// SecretTemplateWithContext is SecretTemplate with a ctx that governs the request. The template is returned from the
+// cache, without a request, if the TemplateCacheTTL is set and the template was read within it.
+func (s Server) SecretTemplateWithContext(ctx context.Context, id int) (*SecretTemplate, error) {
+	if s.templates != nil {
+		if template, found := s.templates.get(id); found {
+			return template, nil
+		}
+	}
+
 	secretTemplate := new(SecretTemplate)
 
-	if data, err := s.accessResource("GET", templateResource, strconv.Itoa(id), nil); err == nil {
-		if err = json.Unmarshal(data, secretTemplate); err != nil {
-			log.Printf("[ERROR] error parsing response from /%s/%d: %q", templateResource, id, data)
+	if data, err := s.accessResourceWithContext(ctx, "GET", templateResource, strconv.Itoa(id), nil); err == nil {
+		if err = s.unmarshal(data, secretTemplate); err != nil {
+			s.logger().Errorf("error parsing response from /%s/%d: %s", templateResource, id, redactBody(data))
 			return nil, err
 		}
 	} else {
 		return nil, err
 	}
 
+	if s.templates != nil {
+		s.templates.put(secretTemplate)
+	}
 	return secretTemplate, nil
 }
 
+// ClearTemplateCache removes every template from the cache that the TemplateCacheTTL enables, e.g. after a template
+// has been changed on the server, so that the next read of each template gets it from the server.
+func (s Server) ClearTemplateCache() {
+	if s.templates != nil {
+		s.templates.clear()
+	}
+}
+
+// NewSecretFromTemplate returns a new secret of the template with the given id, with an empty field for each of the
+// template's fields, so that only their ItemValues need to be set before the secret is passed to CreateSecret.
+func (s Server) NewSecretFromTemplate(templateID int) (*Secret, error) {
+	template, err := s.SecretTemplate(templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := &Secret{SecretTemplateID: template.ID, Fields: make([]SecretField, 0, len(template.Fields))}
+	for _, field := range template.Fields {
+		secret.Fields = append(secret.Fields, SecretField{
+			FieldID:    field.SecretTemplateFieldID,
+			FieldName:  field.Name,
+			Slug:       field.FieldSlugName,
+			IsFile:     field.IsFile,
+			IsNotes:    field.IsNotes,
+			IsPassword: field.IsPassword,
+		})
+	}
+
+	return secret, nil
+}
+
+// SecretTemplates gets the secret templates from the Secret Server of the given tenant, without their fields; get those
+// with SecretTemplate as needed.
+func (s Server) SecretTemplates() ([]SecretTemplate, error) {
+	templates := make([]SecretTemplate, 0)
+
+	err := s.eachPage(context.Background(), templateResource, "", nil, func(record json.RawMessage) error {
+		var template SecretTemplate
+		if err := json.Unmarshal(record, &template); err != nil {
+			return err
+		}
+		templates = append(templates, template)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// SecretTemplateNotFoundError is returned when no secret template has the given name
+type SecretTemplateNotFoundError struct {
+	Name string
+}
+
+func (e *SecretTemplateNotFoundError) Error() string {
+	return fmt.Sprintf("no secret template with name '%s'", e.Name)
+}
+
+// MultipleTemplatesFoundError is returned when more than one secret template has the given name
+type MultipleTemplatesFoundError struct {
+	IDs  []int
+	Name string
+}
+
+func (e *MultipleTemplatesFoundError) Error() string {
+	return fmt.Sprintf("%d secret templates with name '%s': %v", len(e.IDs), e.Name, e.IDs)
+}
+
+// SecretTemplateNameToID returns the ID of the secret template with the given name, ignoring case, e.g. so that
+// "Active Directory Account" can be used as the SecretTemplateID of a new secret. It returns a
+// *SecretTemplateNotFoundError if there is no such template and a *MultipleTemplatesFoundError if there is more than
+// one.
+func (s Server) SecretTemplateNameToID(name string) (int, error) {
+	templates, err := s.SecretTemplates()
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]int, 0)
+	for _, template := range templates {
+		if strings.EqualFold(template.Name, name) {
+			ids = append(ids, template.ID)
+		}
+	}
+
+	switch len(ids) {
+	case 0:
+		return 0, &SecretTemplateNotFoundError{Name: name}
+	case 1:
+		return ids[0], nil
+	default:
+		return 0, &MultipleTemplatesFoundError{IDs: ids, Name: name}
+	}
+}
+
 // GeneratePassword generates and returns a password for the secret field identified by the given slug on the given
 // template. The password adheres to the password requirements associated with the field. NOTE: this should only be
 // used with fields whose IsPassword property is true.
@@ -48,16 +164,112 @@ func (s Server) GeneratePassword(slug string, template *SecretTemplate) (string,
 	fieldId, found := template.FieldSlugToId(slug)
 
 	if !found {
-		log.Printf("[ERROR] the alias '%s' does not identify a field on the template named '%s'", slug, template.Name)
+		return "", fmt.Errorf("[ERROR] the alias '%s' does not identify a field on the template named '%s'", slug, template.Name)
 	}
 	path := fmt.Sprintf("generate-password/%d", fieldId)
 
+	var password string
+
 	if data, err := s.accessResource("POST", templateResource, path, nil); err == nil {
-		passwordWithQuotes := string(data)
-		return passwordWithQuotes[1 : len(passwordWithQuotes)-1], nil
+		// the password is a JSON string, so do not log the body
+		if err = json.Unmarshal(data, &password); err != nil {
+			s.logger().Errorf("error parsing the generated password from /%s/%s: %s", templateResource, path, err)
+			return "", err
+		}
 	} else {
 		return "", err
 	}
+
+	return password, nil
+}
+
+// GeneratePasswordForTemplate generates and returns a password for the secret field identified by the given slug on
+// the template with the given id, as GeneratePassword does.
+func (s Server) GeneratePasswordForTemplate(templateID int, slug string) (string, error) {
+	template, err := s.SecretTemplate(templateID)
+	if err != nil {
+		return "", err
+	}
+	return s.GeneratePassword(slug, template)
+}
+
+// FieldValidationError lists the problems found when validating the fields of a secret against its template
+type FieldValidationError struct {
+	TemplateID int
+	Problems   []string
+}
+
+func (e *FieldValidationError) Error() string {
+	return fmt.Sprintf("the fields are not valid for the secret template with id '%d': %s",
+		e.TemplateID, strings.Join(e.Problems, "; "))
+}
+
+// ValidateSecretFields checks the given fields against the template with the given id, and returns a
+// *FieldValidationError listing every field that is not defined on the template and every required field that has
+// no value. A required field that is empty but has a default value on the template is not a problem, since the
+// server fills it in.
+func (s Server) ValidateSecretFields(templateID int, fields []SecretField) error {
+	_, err := s.ValidateSecretFieldsWithDefaults(templateID, fields)
+	return err
+}
+
+// ValidateSecretFieldsWithDefaults is ValidateSecretFields that also returns a copy of the given fields with the
+// template's default values applied, filling in every field that is empty, or missing, and has a default value, so
+// that the copy can be used as the Fields of a new secret.
+func (s Server) ValidateSecretFieldsWithDefaults(templateID int, fields []SecretField) ([]SecretField, error) {
+	template, err := s.SecretTemplate(templateID)
+	if err != nil {
+		return nil, err
+	}
+	return template.validateFields(fields)
+}
+
+// validateFields checks the given fields against this template, and returns a copy of them with the template's
+// default values applied
+func (s SecretTemplate) validateFields(fields []SecretField) ([]SecretField, error) {
+	var problems []string
+	defaulted := make([]SecretField, 0, len(fields))
+	values := make(map[string]string)
+
+	for _, field := range fields {
+		slug := field.Slug
+		if slug == "" {
+			var found bool
+			if slug, found = s.FieldIdToSlug(field.FieldID); !found {
+				problems = append(problems, fmt.Sprintf("field id '%d' is not defined on the template", field.FieldID))
+				continue
+			}
+		}
+		templateField, found := s.GetField(slug)
+		if !found {
+			problems = append(problems, fmt.Sprintf("field '%s' is not defined on the template", slug))
+			continue
+		}
+		if field.ItemValue == "" {
+			field.ItemValue = templateField.DefaultValue
+		}
+		values[slug] = field.ItemValue
+		defaulted = append(defaulted, field)
+	}
+
+	for _, field := range s.Fields {
+		if _, given := values[field.FieldSlugName]; !given && field.DefaultValue != "" {
+			values[field.FieldSlugName] = field.DefaultValue
+			defaulted = append(defaulted, SecretField{
+				FieldID:   field.SecretTemplateFieldID,
+				Slug:      field.FieldSlugName,
+				ItemValue: field.DefaultValue,
+			})
+		}
+		if field.IsRequired && values[field.FieldSlugName] == "" {
+			problems = append(problems, fmt.Sprintf("required field '%s' has no value", field.FieldSlugName))
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, &FieldValidationError{TemplateID: s.ID, Problems: problems}
+	}
+	return defaulted, nil
 }
 
 // FieldIdToSlug returns the shorthand alias (aka: "slug") of the field with the given field ID, and a boolean
@@ -65,11 +277,9 @@ func (s Server) GeneratePassword(slug string, template *SecretTemplate) (string,
 func (s SecretTemplate) FieldIdToSlug(fieldId int) (string, bool) {
 	for _, field := range s.Fields {
 		if fieldId == field.SecretTemplateFieldID {
-			log.Printf("[TRACE] template field with slug '%s' matches the given ID '%d'", field.FieldSlugName, fieldId)
 			return field.FieldSlugName, true
 		}
 	}
-	log.Printf("[ERROR] no matching template field with id '%d' in template '%s'", fieldId, s.Name)
 	return "", false
 }
 
@@ -88,10 +298,8 @@ func (s SecretTemplate) FieldSlugToId(slug string) (int, bool) {
 func (s SecretTemplate) GetField(slug string) (*SecretTemplateField, bool) {
 	for _, field := range s.Fields {
 		if slug == field.FieldSlugName {
-			log.Printf("[TRACE] template field with ID '%d' matches the given slug '%s'", field.SecretTemplateFieldID, slug)
 			return &field, true
 		}
 	}
-	log.Printf("[ERROR] no matching template field with slug '%s' in template '%s'", slug, s.Name)
 	return nil, false
 }
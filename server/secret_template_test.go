@@ -1,7 +1,11 @@
 package server
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
 	"testing"
+	"time"
 )
 
 // TestSecretTemplate tests SecretTemplate. Referred to as
@@ -61,3 +65,197 @@ func TestSecretTemplate(t *testing.T) {
 		}
 	}
 }
+
+// TestGeneratePassword validates that the generated password is decoded from
+// the JSON string that the server returns, and that a body that is not one is
+// an error.
+func TestGeneratePassword(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.respond("POST", "/api/v1/secret-templates/generate-password/109", http.StatusOK, `"p\"a\\ss\u0026"`)
+	f.respond("POST", "/api/v1/secret-templates/generate-password/110", http.StatusOK, `"`)
+
+	tss := f.server()
+	template := &SecretTemplate{Fields: []SecretTemplateField{
+		{SecretTemplateFieldID: 109, FieldSlugName: "password"},
+		{SecretTemplateFieldID: 110, FieldSlugName: "pin"},
+	}}
+	password, err := tss.GeneratePassword("password", template)
+	if err != nil {
+		t.Fatal("calling server.GeneratePassword:", err)
+	}
+	validate("password", `p"a\ss&`, password, t)
+
+	if _, err := tss.GeneratePassword("pin", template); err == nil {
+		t.Error("expecting an error from a body that is not a JSON string")
+	}
+}
+
+// TestValidateSecretFields validates that every problem with the fields is
+// reported in a single error.
+func TestValidateSecretFields(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.respondWithFile("GET", "/api/v1/secret-templates/6001", "secret-template.json")
+
+	tss := f.server()
+	valid := []SecretField{{Slug: "username", ItemValue: "svc-app"}, {FieldID: 109, ItemValue: "Passw0rd."}}
+	if err := tss.ValidateSecretFields(6001, valid); err != nil {
+		t.Errorf("expecting the fields to be valid, but found '%v'", err)
+	}
+
+	invalid := []SecretField{{Slug: "username", ItemValue: "svc-app"}, {Slug: "hostname", ItemValue: "example.local"}, {FieldID: 999}}
+	err := tss.ValidateSecretFields(6001, invalid)
+	var validationErr *FieldValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expecting a *FieldValidationError, but found '%v' instead", err)
+	}
+	if len(validationErr.Problems) != 3 {
+		t.Errorf("expecting 3 problems, but found %d instead: %s", len(validationErr.Problems), err)
+	}
+}
+
+// TestSecretTemplateNameToID validates that a template name resolves to the one
+// template with that name, across pages, and that ambiguous names are reported.
+func TestSecretTemplateNameToID(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.handle("GET", "/api/v1/secret-templates", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("paging.skip") == "0" {
+			fmt.Fprint(w, `{"records": [{"id": 6001, "name": "Password"}, {"id": 6002, "name": "Active Directory Account"}],
+				"hasNext": true}`)
+		} else {
+			fmt.Fprint(w, `{"records": [{"id": 6003, "name": "Unix Account (SSH)"}, {"id": 6004, "name": "password"}],
+				"hasNext": false}`)
+		}
+	})
+
+	tss := f.server()
+	id, err := tss.SecretTemplateNameToID("active directory account")
+	if err != nil {
+		t.Fatal("calling server.SecretTemplateNameToID:", err)
+	}
+	validate("template id", 6002, id, t)
+
+	_, err = tss.SecretTemplateNameToID("Password")
+	var multiple *MultipleTemplatesFoundError
+	if !errors.As(err, &multiple) || len(multiple.IDs) != 2 {
+		t.Errorf("expecting a *MultipleTemplatesFoundError with 2 ids, but found '%v' instead", err)
+	}
+
+	_, err = tss.SecretTemplateNameToID("Oracle Account")
+	var notFound *SecretTemplateNotFoundError
+	if !errors.As(err, &notFound) {
+		t.Errorf("expecting a *SecretTemplateNotFoundError, but found '%v' instead", err)
+	}
+}
+
+// TestNewSecretFromTemplate validates that the new secret has a field for each
+// field of the template, which can be set by slug and created as it is.
+func TestNewSecretFromTemplate(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.respondWithFile("GET", "/api/v1/secret-templates/6001", "secret-template.json")
+
+	tss := f.server()
+	secret, err := tss.NewSecretFromTemplate(6001)
+	if err != nil {
+		t.Fatal("calling server.NewSecretFromTemplate:", err)
+	}
+	if !validate("template id", 6001, secret.SecretTemplateID, t) || !validate("fields", 3, len(secret.Fields), t) {
+		return
+	}
+	if field, found := secret.GetField("password"); !found || field.FieldID != 109 || !field.IsPassword {
+		t.Errorf("expecting a password field with id 109, but found %v instead", field)
+	}
+
+	secret.SetField("username", "svc-app")
+	secret.SetField("password", "Passw0rd.")
+	if err := tss.ValidateSecretFields(6001, secret.Fields); err != nil {
+		t.Errorf("expecting the fields to be valid, but found '%v'", err)
+	}
+}
+
+// TestValidateSecretFieldsWithDefaults validates that the template's default
+// values fill in empty and missing fields, so that a required field with a
+// default is not reported.
+func TestValidateSecretFieldsWithDefaults(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.respond("GET", "/api/v1/secret-templates/6005", http.StatusOK, `{"ID": 6005, "Name": "Active Directory Account",
+		"Fields": [{"SecretTemplateFieldID": 120, "FieldSlugName": "username", "IsRequired": true},
+		{"SecretTemplateFieldID": 121, "FieldSlugName": "domain", "IsRequired": true, "DefaultValue": "CORP"},
+		{"SecretTemplateFieldID": 122, "FieldSlugName": "port", "DefaultValue": "389"}]}`)
+
+	given := []SecretField{{Slug: "username", ItemValue: "svc-app"}, {Slug: "port"}}
+	fields, err := f.server().ValidateSecretFieldsWithDefaults(6005, given)
+	if err != nil {
+		t.Fatal("calling server.ValidateSecretFieldsWithDefaults:", err)
+	}
+	secret := Secret{Fields: fields}
+	if domain, _ := secret.Field("domain"); !validate("domain", "CORP", domain, t) {
+		return
+	}
+	if port, _ := secret.Field("port"); !validate("port", "389", port, t) {
+		return
+	}
+	validate("given port", "", given[1].ItemValue, t)
+
+	if _, err := f.server().ValidateSecretFieldsWithDefaults(6005, nil); err == nil {
+		t.Error("expecting an error for the required username without a default")
+	}
+}
+
+// TestTemplateCache validates that a template is read once within the
+// TemplateCacheTTL, that its copies cannot change it, and that clearing the
+// cache, or the TTL passing, makes the next read get it from the server.
+func TestTemplateCache(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.respondWithFile("GET", "/api/v1/secret-templates/6001", "secret-template.json")
+
+	tss, err := New(Configuration{
+		Credentials:      UserCredential{Username: "fixture-user", Password: "fixture-password"},
+		ServerURL:        f.URL,
+		TemplateCacheTTL: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal("configuring the Server:", err)
+	}
+
+	template, err := tss.SecretTemplate(6001)
+	if err != nil {
+		t.Fatal("calling server.SecretTemplate:", err)
+	}
+	template.Fields[0].FieldSlugName = "changed"
+
+	if _, err := tss.NewSecretFromTemplate(6001); err != nil {
+		t.Fatal("calling server.NewSecretFromTemplate:", err)
+	}
+	template, err = tss.SecretTemplate(6001)
+	if err != nil {
+		t.Fatal("calling server.SecretTemplate:", err)
+	}
+	validate("template requests", 1, f.count("GET", "/api/v1/secret-templates/6001"), t)
+	if template.Fields[0].FieldSlugName == "changed" {
+		t.Error("expecting the cached template to be left as it was")
+	}
+
+	tss.ClearTemplateCache()
+	if _, err := tss.SecretTemplate(6001); err != nil {
+		t.Fatal("calling server.SecretTemplate:", err)
+	}
+	validate("template requests after clearing the cache", 2, f.count("GET", "/api/v1/secret-templates/6001"), t)
+
+	time.Sleep(60 * time.Millisecond)
+	if _, err := tss.SecretTemplate(6001); err != nil {
+		t.Fatal("calling server.SecretTemplate:", err)
+	}
+	validate("template requests after the TTL", 3, f.count("GET", "/api/v1/secret-templates/6001"), t)
+}
@@ -0,0 +1,632 @@
+package server
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestAccessTokenIsCached validates that sequential calls share one access
+// token rather than each requesting a new one.
+func TestAccessTokenIsCached(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.respondWithFile("GET", "/api/v1/secrets/42", "secret.json")
+
+	tss := f.server()
+	for i := 0; i < 2; i++ {
+		if _, err := tss.Secret(42); err != nil {
+			t.Fatal("calling server.Secret:", err)
+		}
+	}
+	if count := f.count("POST", "/oauth2/token"); count != 1 {
+		t.Errorf("expecting 1 token request, but found %d instead", count)
+	}
+}
+
+// TestAccessTokenIsRefreshed validates that a token that expires within the
+// refresh window is replaced.
+func TestAccessTokenIsRefreshed(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.respondWithFile("GET", "/api/v1/secrets/42", "secret.json")
+
+	tss := f.server()
+	tss.TokenRefreshWindow = 1200 * time.Second
+	for i := 0; i < 2; i++ {
+		if _, err := tss.Secret(42); err != nil {
+			t.Fatal("calling server.Secret:", err)
+		}
+	}
+	if count := f.count("POST", "/oauth2/token"); count != 2 {
+		t.Errorf("expecting 2 token requests, but found %d instead", count)
+	}
+}
+
+// TestUnauthorizedTokenIsReplaced validates that a token that is rejected with
+// a 401 is replaced and the request retried, but only once.
+func TestUnauthorizedTokenIsReplaced(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	tokens := 0
+	f.handle("POST", "/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		tokens++
+		fmt.Fprintf(w, `{"access_token": "token-%d", "token_type": "bearer", "expires_in": 1200}`, tokens)
+	})
+	f.handle("GET", "/api/v1/secrets/42", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"ID": 42}`)
+	})
+	f.respond("GET", "/api/v1/secrets/43", http.StatusUnauthorized, "")
+
+	tss := f.server()
+	if _, err := tss.Secret(42); err != nil {
+		t.Fatal("calling server.Secret:", err)
+	}
+	validate("secret requests", 2, f.count("GET", "/api/v1/secrets/42"), t)
+
+	if _, err := tss.Secret(43); err == nil {
+		t.Error("expecting an error for a request that stays unauthorized")
+	}
+	validate("unauthorized secret requests", 2, f.count("GET", "/api/v1/secrets/43"), t)
+	validate("token requests", 3, tokens, t)
+}
+
+// TestTransientFailuresAreRetried validates that a GET which fails with a 503
+// is retried, but that a POST is not, unless RetryWrites is set.
+func TestTransientFailuresAreRetried(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	failures := 0
+	f.handle("GET", "/api/v1/secrets/42", func(w http.ResponseWriter, r *http.Request) {
+		if failures < 2 {
+			failures++
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"ID": 42, "Name": "Test Secret"}`)
+	})
+	f.respond("POST", "/api/v1/secret-templates/generate-password/109", http.StatusServiceUnavailable, "Service Unavailable")
+
+	tss := f.server()
+	tss.RetryBaseDelay = time.Millisecond
+	if _, err := tss.Secret(42); err != nil {
+		t.Fatal("calling server.Secret:", err)
+	}
+	if count := f.count("GET", "/api/v1/secrets/42"); count != 3 {
+		t.Errorf("expecting 3 attempts to read the secret, but found %d instead", count)
+	}
+
+	template := &SecretTemplate{Fields: []SecretTemplateField{{SecretTemplateFieldID: 109, FieldSlugName: "password"}}}
+	if _, err := tss.GeneratePassword("password", template); err == nil {
+		t.Fatal("expecting an error from server.GeneratePassword")
+	}
+	if count := f.count("POST", "/api/v1/secret-templates/generate-password/109"); count != 1 {
+		t.Errorf("expecting 1 attempt to generate a password, but found %d instead", count)
+	}
+
+	tss.RetryWrites = true
+	tss.GeneratePassword("password", template)
+	if count := f.count("POST", "/api/v1/secret-templates/generate-password/109"); count != 4 {
+		t.Errorf("expecting 3 more attempts to generate a password, but found %d instead", count-1)
+	}
+}
+
+// TestFileAndTokenRequestsAreRetried validates that file downloads, searches
+// and token requests are retried and reported to OnResponse as other requests
+// are, and that an upload is wrapped in a RequestError.
+func TestFileAndTokenRequestsAreRetried(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	// each responds with a 503 the first time that it is called
+	flaky := func(method, path, body string) {
+		failed := false
+		f.handle(method, path, func(w http.ResponseWriter, r *http.Request) {
+			if !failed {
+				failed = true
+				w.Header().Set("Retry-After", "0")
+				http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			fmt.Fprint(w, body)
+		})
+	}
+	flaky("POST", "/oauth2/token", `{"access_token": "fixture-token", "token_type": "bearer", "expires_in": 1200}`)
+	flaky("GET", "/api/v1/secrets/43/fields/certificate", "-----BEGIN CERTIFICATE-----")
+	flaky("GET", "/api/v1/secrets", `{"Records": []}`)
+	f.respond("PUT", "/api/v1/secrets/43/fields/certificate", http.StatusBadRequest, `{"message": "Invalid file"}`)
+
+	var infos []ResponseInfo
+	tss := f.server()
+	tss.RetryBaseDelay = time.Millisecond
+	tss.OnResponse = func(info ResponseInfo) {
+		infos = append(infos, info)
+	}
+
+	var certificate bytes.Buffer
+	if _, err := tss.SecretFileAttachment(43, "certificate", &certificate); err != nil {
+		t.Fatal("calling server.SecretFileAttachment:", err)
+	}
+	if _, err := tss.Secrets("test", ""); err != nil {
+		t.Fatal("calling server.Secrets:", err)
+	}
+	err := tss.uploadFileContents(43, "certificate", "cert.pem", strings.NewReader("-----BEGIN CERTIFICATE-----"))
+	var requestError *RequestError
+	if !errors.As(err, &requestError) {
+		t.Fatalf("expecting a *RequestError from uploading the file, but found %v instead", err)
+	}
+
+	validate("file attachment", "-----BEGIN CERTIFICATE-----", certificate.String(), t)
+	validate("token requests", 2, f.count("POST", "/oauth2/token"), t)
+	validate("download requests", 2, f.count("GET", "/api/v1/secrets/43/fields/certificate"), t)
+	validate("search requests", 2, f.count("GET", "/api/v1/secrets"), t)
+	validate("calls to OnResponse", 7, len(infos), t)
+}
+
+// TestHTTPClientIsUsed validates that the configured HTTPClient makes the
+// requests.
+func TestHTTPClientIsUsed(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.respondWithFile("GET", "/api/v1/secrets/42", "secret.json")
+
+	transport := &countingTransport{}
+	tss := f.server()
+	tss.HTTPClient = &http.Client{Transport: transport}
+	if _, err := tss.Secret(42); err != nil {
+		t.Fatal("calling server.Secret:", err)
+	}
+	if transport.count != 2 {
+		t.Errorf("expecting the HTTPClient to make 2 requests, but found %d instead", transport.count)
+	}
+}
+
+// countingTransport counts the requests that it makes with the default transport
+type countingTransport struct {
+	count int
+}
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.count++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// TestRetryDelayIsCapped validates that the backoff of a late attempt, and a
+// long Retry-After, are capped at the maxRetryDelay.
+func TestRetryDelayIsCapped(t *testing.T) {
+	tss := Server{Configuration: Configuration{RetryBaseDelay: defaultRetryBaseDelay}}
+
+	for _, attempt := range []int{1, 2, 10, 35, 40, 100} {
+		if delay := tss.retryDelay(attempt, nil); delay < 0 || delay > maxRetryDelay {
+			t.Errorf("expecting the delay of attempt %d to be at most %s, but found %s", attempt, maxRetryDelay, delay)
+		}
+	}
+	if delay := tss.retryDelay(40, nil); delay < maxRetryDelay/2 {
+		t.Errorf("expecting the delay of attempt 40 to be at least %s, but found %s", maxRetryDelay/2, delay)
+	}
+
+	for _, retryAfter := range []string{"86400", time.Now().Add(24 * time.Hour).UTC().Format(http.TimeFormat)} {
+		res := &http.Response{Header: http.Header{"Retry-After": {retryAfter}}}
+		if delay := tss.retryDelay(1, res); delay != maxRetryDelay {
+			t.Errorf("expecting a Retry-After of %s to be capped at %s, but found %s", retryAfter, maxRetryDelay, delay)
+		}
+	}
+}
+
+// TestNotFoundError validates that a 404 response is reported as a
+// *NotFoundError with the same message as before.
+func TestNotFoundError(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.respond("GET", "/api/v1/secrets/999", http.StatusNotFound, `{"message": "Secret not found"}`)
+
+	_, err := f.server().Secret(999)
+	var notFound *NotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("expecting a *NotFoundError, but found '%v' instead", err)
+	}
+	if !validate("not found resource", "secrets", notFound.Resource, t) || !validate("not found path", "999", notFound.Path, t) {
+		return
+	}
+	if !validate("not found message", `404 Not Found: {"message": "Secret not found"}`, notFound.Error(), t) {
+		return
+	}
+}
+
+// TestRequestError validates that a failed request is reported with its method
+// and URL, wrapping the error that it failed with.
+func TestRequestError(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.respond("GET", "/api/v1/secrets/42", http.StatusForbidden, `{"message": "Access Denied"}`)
+
+	_, err := f.server().Secret(42)
+	var requestErr *RequestError
+	if !errors.As(err, &requestErr) {
+		t.Fatalf("expecting a *RequestError, but found '%v' instead", err)
+	}
+	validate("request method", "GET", requestErr.Method, t)
+	validate("request URL", f.URL+"/api/v1/secrets/42", requestErr.URL, t)
+	validate("request error", "GET "+f.URL+`/api/v1/secrets/42: 403 Forbidden: {"message": "Access Denied"}`, err.Error(), t)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("expecting the *RequestError to wrap the *APIError, but found '%v' instead", err)
+	}
+}
+
+// TestAPIError validates that an unsuccessful response is reported as an
+// *APIError with the message parsed from its body.
+func TestAPIError(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.respond("GET", "/api/v1/secrets/42", http.StatusForbidden,
+		`{"errorCode": "API_AccessDenied", "message": "Access Denied", "messageDetail": "You do not have access"}`)
+
+	_, err := f.server().Secret(42)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expecting an *APIError, but found '%v' instead", err)
+	}
+	if !validate("status code", http.StatusForbidden, apiErr.StatusCode, t) ||
+		!validate("error code", "API_AccessDenied", apiErr.ErrorCode, t) ||
+		!validate("message", "Access Denied", apiErr.Message, t) {
+		return
+	}
+
+	// a *NotFoundError is also an *APIError
+	_, err = f.server().Secret(999)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("expecting an *APIError with a 404 status, but found '%v' instead", err)
+	}
+}
+
+// TestClientCredentialsGrant validates that the client credentials are sent
+// to the token endpoint with the ClientCredentialsGrant.
+func TestClientCredentialsGrant(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.handle("POST", "/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.PostFormValue("grant_type") != ClientCredentialsGrant ||
+			r.PostFormValue("client_id") != "fixture-client" || r.PostFormValue("client_secret") != "fixture-secret" {
+			http.Error(w, `{"error": "invalid_client"}`, http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"access_token": "fixture-token", "token_type": "bearer", "expires_in": 1200}`)
+	})
+	f.respondWithFile("GET", "/api/v1/secrets/42", "secret.json")
+
+	tss, err := New(Configuration{
+		Credentials: UserCredential{ClientID: "fixture-client", ClientSecret: "fixture-secret"},
+		GrantType:   ClientCredentialsGrant,
+		ServerURL:   f.URL,
+	})
+	if err != nil {
+		t.Fatal("configuring the Server:", err)
+	}
+	if _, err := tss.Secret(42); err != nil {
+		t.Error("calling server.Secret:", err)
+	}
+}
+
+// TestDomainIsSent validates that the domain is sent to the token endpoint
+// when it is configured.
+func TestDomainIsSent(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	domain := ""
+	f.handle("POST", "/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		domain = r.PostFormValue("domain")
+		fmt.Fprint(w, `{"access_token": "fixture-token", "token_type": "bearer", "expires_in": 1200}`)
+	})
+	f.respondWithFile("GET", "/api/v1/secrets/42", "secret.json")
+
+	tss := f.server()
+	tss.Credentials.Domain = "CORP"
+	if _, err := tss.Secret(42); err != nil {
+		t.Fatal("calling server.Secret:", err)
+	}
+	validate("domain", "CORP", domain, t)
+}
+
+// TestOnResponse validates that OnResponse is called for successful and
+// unsuccessful requests alike, and for the token request.
+func TestOnResponse(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.respond("GET", "/api/v1/secrets/42", http.StatusOK, `{"ID": 42}`)
+
+	var infos []ResponseInfo
+	tss := f.server()
+	tss.OnResponse = func(info ResponseInfo) {
+		infos = append(infos, info)
+	}
+	tss.Secret(42)
+	tss.Secret(999)
+
+	if len(infos) != 3 {
+		t.Fatalf("expecting 3 calls to OnResponse, but found %d instead", len(infos))
+	}
+	if !validate("token resource", "token", infos[0].Resource, t) ||
+		!validate("first status code", http.StatusOK, infos[1].StatusCode, t) ||
+		!validate("second status code", http.StatusNotFound, infos[2].StatusCode, t) ||
+		!validate("second resource", "secrets", infos[2].Resource, t) {
+		return
+	}
+	if infos[2].Err == nil {
+		t.Error("expecting the second call to have an error")
+	}
+}
+
+// TestAPIPathURI validates that the API and token paths can be configured
+func TestAPIPathURI(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.respond("POST", "/tss/token", http.StatusOK,
+		`{"access_token": "fixture-token", "token_type": "bearer", "expires_in": 1200}`)
+	f.respond("GET", "/tss/api/v2/secrets/42", http.StatusOK, `{"ID": 42}`)
+
+	tss, err := New(Configuration{
+		Credentials:  UserCredential{Username: "fixture-user", Password: "fixture-password"},
+		ServerURL:    f.URL,
+		APIPathURI:   "/tss/api/v2/",
+		TokenPathURI: "tss/token",
+	})
+	if err != nil {
+		t.Fatal("configuring the Server:", err)
+	}
+	if _, err := tss.Secret(42); err != nil {
+		t.Fatal("calling server.Secret:", err)
+	}
+	validate("token requests", 1, f.count("POST", "/tss/token"), t)
+	validate("secret requests", 1, f.count("GET", "/tss/api/v2/secrets/42"), t)
+}
+
+// TestServerURL validates that an on-premise ServerURL is used as the base URL
+// as-is and that it must be absolute.
+func TestServerURL(t *testing.T) {
+	tss, err := New(Configuration{ServerURL: "https://secrets.corp.example/SecretServer/"})
+	if err != nil {
+		t.Fatal("configuring the Server:", err)
+	}
+	validate("secret URL", "https://secrets.corp.example/SecretServer/api/v1/secrets/42",
+		tss.urlFor("secrets", "42"), t)
+	validate("token URL", "https://secrets.corp.example/SecretServer/oauth2/token",
+		tss.urlFor("token", ""), t)
+
+	for _, serverURL := range []string{"secrets.corp.example/SecretServer", "localhost:8080", "/SecretServer"} {
+		if _, err := New(Configuration{ServerURL: serverURL}); err == nil {
+			t.Errorf("expecting an error for ServerURL '%s'", serverURL)
+		}
+	}
+}
+
+// TestRootCAs validates that the RootCAs are trusted by the client that the SDK
+// builds, and that TLSMinVersion is applied to it.
+func TestRootCAs(t *testing.T) {
+	f := newTLSFixture(t)
+	defer f.Close()
+
+	f.respond("GET", "/api/v1/secrets/42", http.StatusOK, `{"ID": 42}`)
+
+	if _, err := f.server().Secret(42); err == nil {
+		t.Fatal("expecting the fixture's certificate not to be trusted by default")
+	}
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(f.Certificate())
+	tss, err := New(Configuration{
+		Credentials:   UserCredential{Username: "fixture-user", Password: "fixture-password"},
+		ServerURL:     f.URL,
+		RootCAs:       rootCAs,
+		TLSMinVersion: tls.VersionTLS12,
+	})
+	if err != nil {
+		t.Fatal("configuring the Server:", err)
+	}
+	if _, err := tss.Secret(42); err != nil {
+		t.Fatal("calling server.Secret:", err)
+	}
+	tlsConfig := tss.httpClient().Transport.(*http.Transport).TLSClientConfig
+	validate("minimum TLS version", uint16(tls.VersionTLS12), tlsConfig.MinVersion, t)
+	if defaultConfig := http.DefaultTransport.(*http.Transport).TLSClientConfig; defaultConfig != nil && defaultConfig.RootCAs == rootCAs {
+		t.Error("expecting the default transport to be left as it was")
+	}
+}
+
+// TestProxyURL validates that requests go through the authenticated proxy at
+// the ProxyURL, and that an invalid ProxyURL is rejected by New.
+func TestProxyURL(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	var proxyAuthorization, host string
+	f.handle("GET", "/api/v1/secrets/42", func(w http.ResponseWriter, r *http.Request) {
+		proxyAuthorization, host = r.Header.Get("Proxy-Authorization"), r.Host
+		fmt.Fprint(w, `{"ID": 42}`)
+	})
+
+	tss, err := New(Configuration{
+		Credentials: UserCredential{Username: "fixture-user", Password: "fixture-password"},
+		ServerURL:   "http://secrets.corp.example",
+		ProxyURL:    strings.Replace(f.URL, "http://", "http://proxy-user:proxy-password@", 1),
+	})
+	if err != nil {
+		t.Fatal("configuring the Server:", err)
+	}
+	if _, err := tss.Secret(42); err != nil {
+		t.Fatal("calling server.Secret:", err)
+	}
+	validate("host", "secrets.corp.example", host, t)
+	if !strings.HasPrefix(proxyAuthorization, "Basic ") {
+		t.Errorf("expecting basic proxy authorization, but found '%s' instead", proxyAuthorization)
+	}
+
+	for _, proxyURL := range []string{"proxy.example:3128", "ftp://proxy.example", "http://%zz"} {
+		if _, err := New(Configuration{ServerURL: "https://secrets.corp.example", ProxyURL: proxyURL}); err == nil {
+			t.Errorf("expecting an error for ProxyURL '%s'", proxyURL)
+		}
+	}
+}
+
+// TestTimeout validates that a request that takes longer than the Timeout
+// fails, while a file download only has to finish within the longer
+// FileDownloadTimeout.
+func TestTimeout(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	slow := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(100 * time.Millisecond)
+			fmt.Fprint(w, body)
+		}
+	}
+	f.handle("GET", "/api/v1/secrets/42", slow(`{"ID": 42}`))
+	f.handle("GET", "/api/v1/secrets/42/fields/key", slow("contents"))
+
+	tss, err := New(Configuration{
+		Credentials:         UserCredential{Username: "fixture-user", Password: "fixture-password"},
+		ServerURL:           f.URL,
+		RetryMaxAttempts:    1,
+		Timeout:             20 * time.Millisecond,
+		FileDownloadTimeout: time.Second,
+	})
+	if err != nil {
+		t.Fatal("configuring the Server:", err)
+	}
+	if _, err := tss.Secret(42); err == nil {
+		t.Error("expecting the request to time out")
+	}
+	var contents strings.Builder
+	if _, err := tss.SecretFileAttachment(42, "key", &contents); err != nil {
+		t.Fatal("calling server.SecretFileAttachment:", err)
+	}
+	validate("contents", "contents", contents.String(), t)
+}
+
+// TestUserAgent validates that every request identifies itself with the
+// UserAgent, which has a default.
+func TestUserAgent(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	var userAgents []string
+	record := func(w http.ResponseWriter, r *http.Request) {
+		userAgents = append(userAgents, r.UserAgent())
+		fmt.Fprint(w, `{"access_token": "fixture-token", "token_type": "bearer", "expires_in": 1200, "ID": 42}`)
+	}
+	f.handle("POST", "/oauth2/token", record)
+	f.handle("GET", "/api/v1/secrets/42", record)
+
+	tss := f.server()
+	if _, err := tss.Secret(42); err != nil {
+		t.Fatal("calling server.Secret:", err)
+	}
+	tss.UserAgent = "inventory-service/1.4"
+	if _, err := tss.Secret(42); err != nil {
+		t.Fatal("calling server.Secret:", err)
+	}
+
+	expected := []string{"tss-sdk-go/v2", "tss-sdk-go/v2", "inventory-service/1.4"}
+	if !validate("user agents", fmt.Sprint(expected), fmt.Sprint(userAgents), t) {
+		return
+	}
+}
+
+// TestStrictJSON validates that fields that the SDK does not know are ignored,
+// unless StrictJSON is set
+func TestStrictJSON(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.respond("GET", "/api/v1/users/current", http.StatusOK, `{"id": 7, "userName": "fixture-user", "favoriteColor": "blue"}`)
+
+	tss := f.server()
+	if _, err := tss.WhoAmI(); err != nil {
+		t.Error("expecting the unknown field to be ignored, but got:", err)
+	}
+
+	tss.StrictJSON = true
+	if _, err := tss.WhoAmI(); err == nil || !strings.Contains(err.Error(), "favoriteColor") {
+		t.Errorf("expecting an error about the unknown field, but got '%v'", err)
+	}
+}
+
+// TestConcurrentUse validates that a Server, and copies of it, can be used by
+// many goroutines at once while its access token is refreshed and replaced.
+// Run it with -race.
+func TestConcurrentUse(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	var tokens int32
+	f.handle("POST", "/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		// tokens that expire within the TokenRefreshWindow are refreshed by
+		// every request
+		fmt.Fprintf(w, `{"access_token": "token-%d", "token_type": "bearer", "expires_in": 1}`, atomic.AddInt32(&tokens, 1))
+	})
+	f.respond("GET", "/api/v1/secrets/42", http.StatusOK,
+		`{"ID": 42, "Items": [{"Slug": "key", "IsFile": true, "FileAttachmentID": 9, "Filename": "id_rsa"}]}`)
+	f.respond("GET", "/api/v1/secrets/42/fields/key", http.StatusOK, "contents")
+	// the unauthorized secret makes its callers replace the token
+	f.respond("GET", "/api/v1/secrets/43", http.StatusUnauthorized, "")
+
+	// the rate limit is high enough not to slow the test, but its limiter is
+	// shared by the goroutines too
+	tss, err := New(Configuration{
+		Credentials: UserCredential{Username: "fixture-user", Password: "fixture-password"},
+		ServerURL:   f.URL,
+		RateLimit:   10000,
+	})
+	if err != nil {
+		t.Fatal("configuring the Server:", err)
+	}
+	var wg sync.WaitGroup
+
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(tss Server) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				secret, err := tss.Secret(42)
+				if err != nil {
+					t.Error("calling server.Secret:", err)
+					return
+				}
+				if value, _ := secret.Field("key"); value != "contents" {
+					t.Errorf("expecting the file contents, but found '%s'", value)
+				}
+				if _, err := tss.Secret(43); err == nil {
+					t.Error("expecting an error for the unauthorized secret")
+				}
+			}
+		}(*tss)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// siteResource is the HTTP URL path component for the sites resource
+const siteResource = "sites"
+
+// engineResource is the HTTP URL path component for the distributed engines
+// resource
+const engineResource = "distributed-engine"
+
+// localSiteName and localSiteID identify the site that Secret Server itself
+// runs on, which every installation has
+const (
+	localSiteName = "Local"
+	localSiteID   = 1
+)
+
+// Site represents a site from Delinea Secret Server, that is, a group of
+// distributed engines that operations on secrets, such as heartbeats, run on
+type Site struct {
+	SiteName string
+	SiteID   int
+	Active   bool
+}
+
+// EngineConnectionStatus is whether a distributed engine is connected to Secret
+// Server
+type EngineConnectionStatus string
+
+// The connection statuses of a distributed engine
+const (
+	EngineOnline  EngineConnectionStatus = "Online"
+	EngineOffline EngineConnectionStatus = "Offline"
+)
+
+// Engine is a distributed engine of a site, which runs the site's operations
+// on secrets
+type Engine struct {
+	EngineID, SiteID               int
+	FriendlyName, ActivationStatus string
+	ConnectionStatus               EngineConnectionStatus
+}
+
+// Online reports whether the engine is connected to Secret Server
+func (e Engine) Online() bool {
+	return strings.EqualFold(string(e.ConnectionStatus), string(EngineOnline))
+}
+
+// SiteStatus is the status of the distributed engines of a site
+type SiteStatus struct {
+	SiteID  int
+	Engines []Engine
+}
+
+// Online reports whether the site can run operations, i.e. whether any of its
+// engines is online. The Local site runs them on Secret Server itself, so it
+// is online without any engines.
+func (s SiteStatus) Online() bool {
+	if s.SiteID == localSiteID {
+		return true
+	}
+	for _, engine := range s.Engines {
+		if engine.Online() {
+			return true
+		}
+	}
+	return false
+}
+
+// Sites gets the sites from the Secret Server of the given tenant
+func (s Server) Sites() ([]Site, error) {
+	sites := make([]Site, 0)
+
+	err := s.eachPage(context.Background(), siteResource, "", nil, func(record json.RawMessage) error {
+		var site Site
+		if err := json.Unmarshal(record, &site); err != nil {
+			return err
+		}
+		sites = append(sites, site)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sites, nil
+}
+
+// SiteNameToID returns the ID of the site with the given name, ignoring case.
+// The Local site resolves to its well-known ID even if it is not listed.
+func (s Server) SiteNameToID(name string) (int, error) {
+	sites, err := s.Sites()
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]int, 0)
+	for _, site := range sites {
+		if strings.EqualFold(site.SiteName, name) {
+			ids = append(ids, site.SiteID)
+		}
+	}
+
+	switch {
+	case len(ids) == 1:
+		return ids[0], nil
+	case len(ids) > 1:
+		return 0, fmt.Errorf("[ERROR] %d sites with name '%s': %v", len(ids), name, ids)
+	case strings.EqualFold(name, localSiteName):
+		return localSiteID, nil
+	default:
+		return 0, fmt.Errorf("[ERROR] no site with name '%s'", name)
+	}
+}
+
+// SiteStatus returns the status of the distributed engines of the site with
+// the given id, e.g. to check that the site is Online before running the
+// heartbeat of a secret through it
+func (s Server) SiteStatus(siteID int) (*SiteStatus, error) {
+	engines := make([]Engine, 0)
+	query := url.Values{"paging.filter.siteId": {strconv.Itoa(siteID)}}
+
+	err := s.eachPage(context.Background(), engineResource, "engines", query, func(record json.RawMessage) error {
+		var engine Engine
+		if err := json.Unmarshal(record, &engine); err != nil {
+			return err
+		}
+		engines = append(engines, engine)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &SiteStatus{SiteID: siteID, Engines: engines}, nil
+}
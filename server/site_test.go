@@ -0,0 +1,94 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestSiteStatus validates that a site is online if any of its engines is,
+// and that the Local site is online without any.
+func TestSiteStatus(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.handle("GET", "/api/v1/distributed-engine/engines", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("paging.filter.siteId") {
+		case "3":
+			w.Write([]byte(`{"records": [{"engineId": 7, "siteId": 3, "friendlyName": "engine-a", "connectionStatus": "Offline"},
+				{"engineId": 8, "siteId": 3, "friendlyName": "engine-b", "connectionStatus": "Online"}]}`))
+		case "4":
+			w.Write([]byte(`{"records": [{"engineId": 9, "siteId": 4, "friendlyName": "engine-c", "connectionStatus": "Offline"}]}`))
+		default:
+			w.Write([]byte(`{"records": []}`))
+		}
+	})
+
+	tss := f.server()
+	for _, test := range []struct {
+		siteID, engines int
+		online          bool
+	}{{3, 2, true}, {4, 1, false}, {5, 0, false}, {localSiteID, 0, true}} {
+		status, err := tss.SiteStatus(test.siteID)
+		if err != nil {
+			t.Fatal("calling server.SiteStatus:", err)
+		}
+		validate("engines", test.engines, len(status.Engines), t)
+		if status.Online() != test.online {
+			t.Errorf("expecting site %d to be online: %t, but found %+v", test.siteID, test.online, status)
+		}
+	}
+}
+
+// TestSites validates that every page of the sites is read, following HasNext.
+func TestSites(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.handle("GET", "/api/v1/sites", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("paging.skip") == "0" {
+			w.Write([]byte(`{"records": [{"siteId": 1, "siteName": "Local", "active": true}], "hasNext": true}`))
+			return
+		}
+		w.Write([]byte(`{"records": [{"siteId": 3, "siteName": "Datacenter", "active": true}], "hasNext": false}`))
+	})
+
+	sites, err := f.server().Sites()
+	if err != nil {
+		t.Fatal("calling server.Sites:", err)
+	}
+	if !validate("sites", 2, len(sites), t) || !validate("pages", 2, f.count("GET", "/api/v1/sites"), t) {
+		return
+	}
+	validate("second site name", "Datacenter", sites[1].SiteName, t)
+}
+
+// TestSiteNameToID validates that a site's name resolves to its ID ignoring
+// case, that the Local site resolves even if it is not listed, and that a
+// duplicate or an unknown name is an error.
+func TestSiteNameToID(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.respond("GET", "/api/v1/sites", http.StatusOK, `{"records": [{"siteId": 3, "siteName": "Datacenter"},
+		{"siteId": 4, "siteName": "Branch"}, {"siteId": 5, "siteName": "branch"}]}`)
+
+	tss := f.server()
+	for _, test := range []struct {
+		name string
+		id   int
+	}{{"Datacenter", 3}, {"DATACENTER", 3}, {"Local", localSiteID}, {"local", localSiteID}} {
+		id, err := tss.SiteNameToID(test.name)
+		if err != nil {
+			t.Errorf("calling server.SiteNameToID for '%s': %s", test.name, err)
+			continue
+		}
+		validate("site id of "+test.name, test.id, id, t)
+	}
+
+	if _, err := tss.SiteNameToID("Branch"); err == nil {
+		t.Error("expecting an error for the name of two sites")
+	}
+	if _, err := tss.SiteNameToID("Nowhere"); err == nil {
+		t.Error("expecting an error for the name of no site")
+	}
+}
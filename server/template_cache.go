@@ -0,0 +1,66 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// templateCache holds the secret templates that have been read, by id, for the
+// TemplateCacheTTL, so that copies of a Server share them
+type templateCache struct {
+	mutex     sync.Mutex
+	ttl       time.Duration
+	templates map[int]cachedTemplate
+}
+
+// cachedTemplate is a template in the templateCache and when it expires
+type cachedTemplate struct {
+	template  SecretTemplate
+	expiresAt time.Time
+}
+
+// newTemplateCache returns an empty templateCache whose templates expire after
+// the given ttl
+func newTemplateCache(ttl time.Duration) *templateCache {
+	return &templateCache{ttl: ttl, templates: make(map[int]cachedTemplate)}
+}
+
+// get returns a copy of the template with the given id, if the cache holds it
+// and it has not expired
+func (c *templateCache) get(id int) (*SecretTemplate, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	cached, found := c.templates[id]
+	if !found {
+		return nil, false
+	}
+	if time.Now().After(cached.expiresAt) {
+		delete(c.templates, id)
+		return nil, false
+	}
+	return copyTemplate(cached.template), true
+}
+
+// put adds a copy of the given template to the cache
+func (c *templateCache) put(template *SecretTemplate) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.templates[template.ID] = cachedTemplate{template: *copyTemplate(*template), expiresAt: time.Now().Add(c.ttl)}
+}
+
+// clear removes every template from the cache
+func (c *templateCache) clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.templates = make(map[int]cachedTemplate)
+}
+
+// copyTemplate returns a copy of the given template with its own Fields, so
+// that callers cannot change the cached template
+func copyTemplate(template SecretTemplate) *SecretTemplate {
+	template.Fields = append([]SecretTemplateField(nil), template.Fields...)
+	return &template
+}
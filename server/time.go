@@ -0,0 +1,48 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// timeLayouts are the layouts of the timestamps that Secret Server returns,
+// which do not always have a time zone
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999"}
+
+// Time is a timestamp from Secret Server. Timestamps without a time zone are
+// taken to be in UTC.
+type Time struct {
+	time.Time
+}
+
+// UnmarshalJSON parses a JSON string in any of the timeLayouts; null and the
+// empty string parse as the zero Time
+func (t *Time) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	if value == "" {
+		t.Time = time.Time{}
+		return nil
+	}
+
+	for _, layout := range timeLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			t.Time = parsed
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot parse '%s' as a time", value)
+}
+
+// MarshalJSON formats the Time as RFC 3339
+func (t Time) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Time.Format(time.RFC3339Nano))
+}
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// TestTimeUnmarshalJSON validates that timestamps with and without a time
+// zone are parsed, and that null and blank timestamps are the zero Time.
+func TestTimeUnmarshalJSON(t *testing.T) {
+	expected := time.Date(2023, 5, 17, 9, 30, 15, 250000000, time.UTC)
+	for _, data := range []string{`"2023-05-17T09:30:15.25Z"`, `"2023-05-17T09:30:15.25"`, `"2023-05-17T11:30:15.25+02:00"`} {
+		var parsed Time
+		if err := json.Unmarshal([]byte(data), &parsed); err != nil {
+			t.Errorf("parsing %s: %s", data, err)
+		} else if !parsed.Equal(expected) {
+			t.Errorf("expecting %s to parse as '%s', but found '%s' instead", data, expected, parsed)
+		}
+	}
+
+	for _, data := range []string{`null`, `""`} {
+		var parsed Time
+		if err := json.Unmarshal([]byte(data), &parsed); err != nil || !parsed.IsZero() {
+			t.Errorf("expecting %s to parse as the zero time, but found '%s' (%v) instead", data, parsed, err)
+		}
+	}
+
+	var parsed Time
+	if err := json.Unmarshal([]byte(`"yesterday"`), &parsed); err == nil {
+		t.Error("expecting an error parsing 'yesterday'")
+	}
+}
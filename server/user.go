@@ -0,0 +1,31 @@
+package server
+
+// userResource is the HTTP URL path component for the users resource
+const userResource = "users"
+
+// currentUserPath is the path of the user that the access token was granted to
+const currentUserPath = "current"
+
+// CurrentUser represents the user that the Server is authenticated as
+type CurrentUser struct {
+	UserName, DisplayName, DomainName, EmailAddress string
+	ID, DomainID                                    int
+	IsApplicationAccount                            bool
+}
+
+// WhoAmI gets the user that the Server is authenticated as, which is useful
+// when checking which account a misconfigured set of credentials resolves to
+func (s Server) WhoAmI() (*CurrentUser, error) {
+	user := new(CurrentUser)
+
+	if data, err := s.accessResource("GET", userResource, currentUserPath, nil); err == nil {
+		if err = s.unmarshal(data, user); err != nil {
+			s.logger().Errorf("error parsing response from /%s/%s: %s", userResource, currentUserPath, redactBody(data))
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
+	return user, nil
+}
@@ -0,0 +1,24 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestWhoAmI validates that WhoAmI returns the current user
+func TestWhoAmI(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	f.respond("GET", "/api/v1/users/current", http.StatusOK,
+		`{"id": 7, "userName": "fixture-user", "displayName": "Fixture User", "domainId": 2, "domainName": "CORP"}`)
+
+	user, err := f.server().WhoAmI()
+	if err != nil {
+		t.Fatal("calling server.WhoAmI:", err)
+	}
+	validate("id", 7, user.ID, t)
+	validate("user name", "fixture-user", user.UserName, t)
+	validate("display name", "Fixture User", user.DisplayName, t)
+	validate("domain", "CORP", user.DomainName, t)
+}